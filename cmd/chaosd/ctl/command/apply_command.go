@@ -0,0 +1,113 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/pingcap/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+// specFile is the file of the experiment spec, - means the standard input
+var specFile string
+
+func NewApplyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply -f FILENAME",
+		Short: "Create a chaos experiment by the spec in YAML or JSON, the experiment of the same name is created only once",
+		Run:   applyCommandFunc,
+	}
+
+	cmd.Flags().StringVarP(&specFile, "filename", "f", "", "the file of the experiment spec, - means the standard input")
+
+	return cmd
+}
+
+func NewDeleteCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "delete -f FILENAME",
+		Short: "Recover the chaos experiment created by applying the spec",
+		Run:   deleteCommandFunc,
+	}
+
+	cmd.Flags().StringVarP(&specFile, "filename", "f", "", "the file of the experiment spec, - means the standard input")
+
+	return cmd
+}
+
+func applyCommandFunc(cmd *cobra.Command, args []string) {
+	spec := mustSpecFromFile(specFile)
+	if err := spec.Validate(); err != nil {
+		ExitWithError(ExitBadArgs, err)
+	}
+
+	chaos := mustChaosdClientFromCmd(cmd, &conf)
+
+	result, err := chaos.Apply(spec)
+	if err != nil {
+		ExitWithChaosdError(err)
+	}
+
+	NormalExit(fmt.Sprintf("Experiment %s %s, uid: %s", result.Name, result.Operation, result.Uid))
+}
+
+func deleteCommandFunc(cmd *cobra.Command, args []string) {
+	spec := mustSpecFromFile(specFile)
+	if len(spec.Name) == 0 {
+		ExitWithMsg(ExitBadArgs, "Error: name is required")
+	}
+
+	chaos := mustChaosdClientFromCmd(cmd, &conf)
+
+	result, err := chaos.Delete(spec.Name)
+	if err != nil {
+		ExitWithChaosdError(err)
+	}
+
+	NormalExit(fmt.Sprintf("Experiment %s %s, uid: %s", result.Name, result.Operation, result.Uid))
+}
+
+func mustSpecFromFile(file string) *core.ExperimentSpec {
+	if len(file) == 0 {
+		ExitWithMsg(ExitBadArgs, "Error: the file of the experiment spec is required")
+	}
+
+	spec, err := core.ParseExperimentSpec(mustReadFile(file))
+	if err != nil {
+		ExitWithError(ExitBadArgs, err)
+	}
+
+	return spec
+}
+
+// mustReadFile reads the file, - means the standard input
+func mustReadFile(file string) []byte {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		ExitWithError(ExitBadArgs, errors.WithStack(err))
+	}
+
+	return data
+}
@@ -0,0 +1,207 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chaos-mesh/chaosd/pkg/client"
+	"github.com/chaos-mesh/chaosd/pkg/config"
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd"
+	"github.com/chaos-mesh/chaosd/pkg/server/utils"
+)
+
+// serverEnv is the environment variable of the remote chaosd server address
+const serverEnv = "CHAOSD_SERVER"
+
+// serverAddr is the address of the remote chaosd server, the commands run locally if it is empty
+var serverAddr string
+
+// AddServerFlag adds the flag of the remote chaosd server to the command and its subcommands
+func AddServerFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&serverAddr, "server", os.Getenv(serverEnv),
+		"the address of the chaosd server, such as http://127.0.0.1:31767, the commands are sent to the server "+
+			"instead of running locally if it is set. It can also be set by the "+serverEnv+" environment variable")
+}
+
+// chaosdClient runs the experiments by the local chaosd or the remote chaosd server
+type chaosdClient interface {
+	ProcessAttack(attack *core.ProcessCommand) (string, error)
+	MatchProcesses(attack *core.ProcessCommand) ([]*core.ProcessInfo, error)
+	NetworkAttack(attack *core.NetworkCommand) (string, error)
+	StressAttack(attack *core.StressCommand) (string, error)
+	RecoverAttack(uid string) error
+	Search(conds *core.SearchCommand) ([]*core.Experiment, error)
+	Apply(spec *core.ExperimentSpec) (*core.ApplyResult, error)
+	Delete(name string) (*core.ApplyResult, error)
+}
+
+// mustChaosdClientFromCmd returns the remote chaosd server if the server address is set, otherwise the local chaosd
+func mustChaosdClientFromCmd(cmd *cobra.Command, conf *config.Config) chaosdClient {
+	if len(serverAddr) > 0 {
+		return &remoteChaosd{client: newRemoteClient()}
+	}
+
+	return &localChaosd{
+		Server: mustChaosdFromCmd(cmd, conf),
+		exp:    mustExpStoreFromCmd(),
+	}
+}
+
+// mustRemoteClientFromCmd returns the client of the remote chaosd server, it exits if the server address is not set
+func mustRemoteClientFromCmd(cmd *cobra.Command) *client.Client {
+	if len(serverAddr) == 0 {
+		ExitWithMsg(ExitBadArgs, "Error: "+cmd.CommandPath()+" is run by chaosd server, "+
+			"set the address of the server by --server or the "+serverEnv+" environment variable")
+	}
+
+	return newRemoteClient()
+}
+
+func newRemoteClient() *client.Client {
+	return client.NewClient(client.Config{
+		Addr:       serverAddr,
+		MaxRetries: 3,
+	})
+}
+
+// mustLocal exits if the command can only run locally but the remote chaosd server is set
+func mustLocal(cmd *cobra.Command) {
+	if len(serverAddr) > 0 {
+		ExitWithMsg(ExitBadArgs, "Error: "+cmd.CommandPath()+" is not supported by the remote chaosd server")
+	}
+}
+
+type localChaosd struct {
+	*chaosd.Server
+	exp core.ExperimentStore
+}
+
+func (l *localChaosd) ProcessAttack(attack *core.ProcessCommand) (string, error) {
+	return l.Server.ProcessAttack(attack, core.ExperimentOrigin{})
+}
+
+func (l *localChaosd) NetworkAttack(attack *core.NetworkCommand) (string, error) {
+	return l.Server.NetworkAttack(attack, core.ExperimentOrigin{})
+}
+
+func (l *localChaosd) StressAttack(attack *core.StressCommand) (string, error) {
+	return l.Server.StressAttack(attack, core.ExperimentOrigin{})
+}
+
+func (l *localChaosd) RecoverAttack(uid string) error {
+	return utils.RecoverExp(l.exp, l.Server, uid)
+}
+
+func (l *localChaosd) Apply(spec *core.ExperimentSpec) (*core.ApplyResult, error) {
+	return utils.ApplyExp(l.exp, l.Server, spec)
+}
+
+func (l *localChaosd) Delete(name string) (*core.ApplyResult, error) {
+	return utils.DeleteExp(l.exp, l.Server, name)
+}
+
+type remoteChaosd struct {
+	client *client.Client
+}
+
+func (r *remoteChaosd) ProcessAttack(attack *core.ProcessCommand) (string, error) {
+	resp, err := r.client.CreateProcessAttack(context.Background(), attack)
+	if err != nil {
+		return "", err
+	}
+
+	return resp.UID, nil
+}
+
+func (r *remoteChaosd) MatchProcesses(attack *core.ProcessCommand) ([]*core.ProcessInfo, error) {
+	return r.client.MatchProcesses(context.Background(), attack)
+}
+
+func (r *remoteChaosd) NetworkAttack(attack *core.NetworkCommand) (string, error) {
+	resp, err := r.client.CreateNetworkAttack(context.Background(), attack)
+	if err != nil {
+		return "", err
+	}
+
+	return resp.UID, nil
+}
+
+func (r *remoteChaosd) StressAttack(attack *core.StressCommand) (string, error) {
+	resp, err := r.client.CreateStressAttack(context.Background(), attack)
+	if err != nil {
+		return "", err
+	}
+
+	return resp.UID, nil
+}
+
+func (r *remoteChaosd) RecoverAttack(uid string) error {
+	_, err := r.client.RecoverAttack(context.Background(), uid)
+	return err
+}
+
+func (r *remoteChaosd) Search(conds *core.SearchCommand) ([]*core.Experiment, error) {
+	if len(conds.UID) > 0 {
+		detail, err := r.client.GetExperiment(context.Background(), conds.UID)
+		if err != nil {
+			return nil, err
+		}
+
+		return []*core.Experiment{detail.Experiment}, nil
+	}
+
+	// the kind and status are ignored when listing all the experiments
+	if conds.All {
+		conds = &core.SearchCommand{Asc: conds.Asc, Limit: conds.Limit, Offset: conds.Offset}
+	}
+
+	list, err := r.client.ListExperiments(context.Background(), conds)
+	if err != nil {
+		return nil, err
+	}
+
+	return list.Experiments, nil
+}
+
+func (r *remoteChaosd) Apply(spec *core.ExperimentSpec) (*core.ApplyResult, error) {
+	return r.client.ApplyExperiment(context.Background(), spec)
+}
+
+func (r *remoteChaosd) Delete(name string) (*core.ApplyResult, error) {
+	return r.client.DeleteExperiment(context.Background(), name)
+}
+
+// ExitWithChaosdError exits with the error returned by chaosd. The error responded by the remote chaosd server
+// is printed in the same way as the local error, and the bad request is exited with ExitBadArgs.
+func ExitWithChaosdError(err error) {
+	apiErr, ok := client.AsAPIError(err)
+	if !ok {
+		ExitWithError(ExitError, err)
+	}
+
+	code := ExitError
+	if apiErr.StatusCode == http.StatusBadRequest {
+		code = ExitBadArgs
+	}
+
+	// the message is prefixed with the type of error, such as error.api.internal_server_error
+	ExitWithMsg(code, "Error: "+strings.TrimPrefix(apiErr.Message, apiErr.Code+": "))
+}
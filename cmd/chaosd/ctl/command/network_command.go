@@ -15,7 +15,9 @@ package command
 
 import (
 	"fmt"
+	"os"
 
+	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 
 	"github.com/chaos-mesh/chaosd/pkg/core"
@@ -32,8 +34,17 @@ func NewNetworkAttackCommand() *cobra.Command {
 		NewNetworkLossCommand(),
 		NewNetworkCorruptCommand(),
 		NetworkDuplicateCommand(),
+		NewNetworkPartitionCommand(),
+		NewNetworkBandwidthCommand(),
+		NewNetworkReorderCommand(),
+		NewNetworkNetemCommand(),
+		NewNetworkProfileCommand(),
 	)
 
+	cmd.PersistentFlags().StringVar(&nFlag.Container, "container", "",
+		"the container whose network namespace the attack is applied in, such as docker://<id> or containerd://<id>. "+
+			"Default is empty that means the host")
+
 	return cmd
 }
 
@@ -53,6 +64,9 @@ func NewNetworkDelayCommand() *cobra.Command {
 		"jitter time, time units: ns, us (or µs), ms, s, m, h.")
 	cmd.Flags().StringVarP(&nFlag.Correlation, "correlation", "c", "0", "correlation is percentage (10 is 10%)")
 	cmd.Flags().StringVarP(&nFlag.Device, "device", "d", "", "the network interface to impact")
+	cmd.Flags().StringVar(&nFlag.Direction, "direction", "",
+		"the direction of the traffic to impact, supported: egress, ingress, both. Default is egress. "+
+			"The ingress traffic is redirected to an IFB device to be impacted")
 	cmd.Flags().StringVarP(&nFlag.EgressPort, "egress-port", "e", "",
 		"only impact egress traffic to these destination ports, use a ',' to separate or to indicate the range, such as 80, 8001:8010. "+
 			"It can only be used in conjunction with -p tcp or -p udp")
@@ -61,8 +75,10 @@ func NewNetworkDelayCommand() *cobra.Command {
 			"It can only be used in conjunction with -p tcp or -p udp")
 	cmd.Flags().StringVarP(&nFlag.IPAddress, "ip", "i", "", "only impact egress traffic to these IP addresses")
 	cmd.Flags().StringVarP(&nFlag.Hostname, "hostname", "H", "", "only impact traffic to these hostnames")
+	cmd.Flags().StringVar(&nFlag.IPFamily, "ip-family", "", "the IP family of the traffic to impact, supported: ipv4, ipv6. Default is both of them")
 	cmd.Flags().StringVarP(&nFlag.IPProtocol, "protocol", "p", "",
 		"only impact traffic using this IP protocol, supported: tcp, udp, icmp, all")
+	addDurationFlag(cmd, &nFlag.Duration)
 
 	return cmd
 }
@@ -78,6 +94,9 @@ func NewNetworkLossCommand() *cobra.Command {
 	cmd.Flags().StringVar(&nFlag.Percent, "percent", "1", "percentage of packets to drop (10 is 10%)")
 	cmd.Flags().StringVarP(&nFlag.Correlation, "correlation", "c", "0", "correlation is percentage (10 is 10%)")
 	cmd.Flags().StringVarP(&nFlag.Device, "device", "d", "", "the network interface to impact")
+	cmd.Flags().StringVar(&nFlag.Direction, "direction", "",
+		"the direction of the traffic to impact, supported: egress, ingress, both. Default is egress. "+
+			"The ingress traffic is redirected to an IFB device to be impacted")
 	cmd.Flags().StringVarP(&nFlag.EgressPort, "egress-port", "e", "",
 		"only impact egress traffic to these destination ports, use a ',' to separate or to indicate the range, such as 80, 8001:8010. "+
 			"It can only be used in conjunction with -p tcp or -p udp")
@@ -86,8 +105,10 @@ func NewNetworkLossCommand() *cobra.Command {
 			"It can only be used in conjunction with -p tcp or -p udp")
 	cmd.Flags().StringVarP(&nFlag.IPAddress, "ip", "i", "", "only impact egress traffic to these IP addresses")
 	cmd.Flags().StringVarP(&nFlag.Hostname, "hostname", "H", "", "only impact traffic to these hostnames")
+	cmd.Flags().StringVar(&nFlag.IPFamily, "ip-family", "", "the IP family of the traffic to impact, supported: ipv4, ipv6. Default is both of them")
 	cmd.Flags().StringVarP(&nFlag.IPProtocol, "protocol", "p", "",
 		"only impact traffic using this IP protocol, supported: tcp, udp, icmp, all")
+	addDurationFlag(cmd, &nFlag.Duration)
 
 	return cmd
 }
@@ -103,6 +124,9 @@ func NewNetworkCorruptCommand() *cobra.Command {
 	cmd.Flags().StringVar(&nFlag.Percent, "percent", "1", "percentage of packets to corrupt (10 is 10%)")
 	cmd.Flags().StringVarP(&nFlag.Correlation, "correlation", "c", "0", "correlation is percentage (10 is 10%)")
 	cmd.Flags().StringVarP(&nFlag.Device, "device", "d", "", "the network interface to impact")
+	cmd.Flags().StringVar(&nFlag.Direction, "direction", "",
+		"the direction of the traffic to impact, supported: egress, ingress, both. Default is egress. "+
+			"The ingress traffic is redirected to an IFB device to be impacted")
 	cmd.Flags().StringVarP(&nFlag.EgressPort, "egress-port", "e", "",
 		"only impact egress traffic to these destination ports, use a ',' to separate or to indicate the range, such as 80, 8001:8010. "+
 			"It can only be used in conjunction with -p tcp or -p udp")
@@ -111,8 +135,10 @@ func NewNetworkCorruptCommand() *cobra.Command {
 			"It can only be used in conjunction with -p tcp or -p udp")
 	cmd.Flags().StringVarP(&nFlag.IPAddress, "ip", "i", "", "only impact egress traffic to these IP addresses")
 	cmd.Flags().StringVarP(&nFlag.Hostname, "hostname", "H", "", "only impact traffic to these hostnames")
+	cmd.Flags().StringVar(&nFlag.IPFamily, "ip-family", "", "the IP family of the traffic to impact, supported: ipv4, ipv6. Default is both of them")
 	cmd.Flags().StringVarP(&nFlag.IPProtocol, "protocol", "p", "",
 		"only impact traffic using this IP protocol, supported: tcp, udp, icmp, all")
+	addDurationFlag(cmd, &nFlag.Duration)
 
 	return cmd
 }
@@ -128,6 +154,160 @@ func NetworkDuplicateCommand() *cobra.Command {
 	cmd.Flags().StringVar(&nFlag.Percent, "percent", "1", "percentage of packets to corrupt (10 is 10%)")
 	cmd.Flags().StringVarP(&nFlag.Correlation, "correlation", "c", "0", "correlation is percentage (10 is 10%)")
 	cmd.Flags().StringVarP(&nFlag.Device, "device", "d", "", "the network interface to impact")
+	cmd.Flags().StringVar(&nFlag.Direction, "direction", "",
+		"the direction of the traffic to impact, supported: egress, ingress, both. Default is egress. "+
+			"The ingress traffic is redirected to an IFB device to be impacted")
+	cmd.Flags().StringVarP(&nFlag.EgressPort, "egress-port", "e", "",
+		"only impact egress traffic to these destination ports, use a ',' to separate or to indicate the range, such as 80, 8001:8010. "+
+			"It can only be used in conjunction with -p tcp or -p udp")
+	cmd.Flags().StringVarP(&nFlag.SourcePort, "source-port", "s", "",
+		"only impact egress traffic from these source ports, use a ',' to separate or to indicate the range, such as 80, 8001:8010. "+
+			"It can only be used in conjunction with -p tcp or -p udp")
+	cmd.Flags().StringVarP(&nFlag.IPAddress, "ip", "i", "", "only impact egress traffic to these IP addresses")
+	cmd.Flags().StringVarP(&nFlag.Hostname, "hostname", "H", "", "only impact traffic to these hostnames")
+	cmd.Flags().StringVar(&nFlag.IPFamily, "ip-family", "", "the IP family of the traffic to impact, supported: ipv4, ipv6. Default is both of them")
+	cmd.Flags().StringVarP(&nFlag.IPProtocol, "protocol", "p", "",
+		"only impact traffic using this IP protocol, supported: tcp, udp, icmp, all")
+	addDurationFlag(cmd, &nFlag.Duration)
+
+	return cmd
+}
+
+func NewNetworkPartitionCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "partition",
+		Short: "block the traffic between the host and the targets",
+
+		Run: networkPartitionCommandFunc,
+	}
+
+	cmd.Flags().StringVar(&nFlag.Direction, "direction", "",
+		"the direction of the traffic to block, supported: to, from, both. Default is both")
+	cmd.Flags().StringVarP(&nFlag.IPAddress, "ip", "i", "", "only impact traffic to/from these IP addresses")
+	cmd.Flags().StringVarP(&nFlag.Hostname, "hostname", "H", "", "only impact traffic to/from these hostnames")
+	cmd.Flags().StringVar(&nFlag.IPFamily, "ip-family", "", "the IP family of the traffic to impact, supported: ipv4, ipv6. Default is both of them")
+	cmd.Flags().StringVar(&nFlag.Port, "port", "",
+		"only impact traffic to/from these ports of the targets, use a ',' to separate or to indicate the range, such as 80, 8001:8010. "+
+			"It can only be used in conjunction with -p tcp or -p udp")
+	cmd.Flags().StringVarP(&nFlag.IPProtocol, "protocol", "p", "",
+		"only impact traffic using this IP protocol, supported: tcp, udp, icmp, all")
+	cmd.Flags().BoolVar(&nFlag.AcceptList, "accept-list", false,
+		"only accept the traffic to/from the IP addresses and hostnames, and block others")
+	cmd.Flags().BoolVar(&nFlag.Reject, "reject", false,
+		"reject the blocked packets with an ICMP error instead of dropping them silently")
+	addDurationFlag(cmd, &nFlag.Duration)
+
+	return cmd
+}
+
+func NewNetworkBandwidthCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bandwidth",
+		Short: "limit network bandwidth",
+
+		Run: networkBandwidthCommandFunc,
+	}
+
+	cmd.Flags().StringVarP(&nFlag.Rate, "rate", "r", "",
+		"the speed knob, allows bps, kbps, mbps, gbps, tbps unit. bps means bytes per second, "+
+			"and bit based units bit, kbit, mbit, gbit, tbit are also allowed")
+	cmd.Flags().Uint32VarP(&nFlag.Limit, "limit", "l", 0,
+		"the number of bytes that can be queued waiting for tokens to become available")
+	cmd.Flags().Uint32VarP(&nFlag.Buffer, "buffer", "b", 0,
+		"the maximum amount of bytes that tokens can be available for instantaneously")
+	cmd.Flags().Uint64Var(&nFlag.Peakrate, "peakrate", 0,
+		"the maximum depletion rate of the bucket, it can only be used in conjunction with --minburst")
+	cmd.Flags().Uint32Var(&nFlag.Minburst, "minburst", 0,
+		"the size of the peakrate bucket, it can only be used in conjunction with --peakrate")
+	cmd.Flags().StringVarP(&nFlag.Device, "device", "d", "", "the network interface to impact")
+	cmd.Flags().StringVar(&nFlag.Direction, "direction", "",
+		"the direction of the traffic to impact, supported: egress, ingress, both. Default is egress. "+
+			"The ingress traffic is redirected to an IFB device to be impacted")
+	cmd.Flags().StringVarP(&nFlag.EgressPort, "egress-port", "e", "",
+		"only impact egress traffic to these destination ports, use a ',' to separate or to indicate the range, such as 80, 8001:8010. "+
+			"It can only be used in conjunction with -p tcp or -p udp")
+	cmd.Flags().StringVarP(&nFlag.SourcePort, "source-port", "s", "",
+		"only impact egress traffic from these source ports, use a ',' to separate or to indicate the range, such as 80, 8001:8010. "+
+			"It can only be used in conjunction with -p tcp or -p udp")
+	cmd.Flags().StringVarP(&nFlag.IPAddress, "ip", "i", "", "only impact egress traffic to these IP addresses")
+	cmd.Flags().StringVarP(&nFlag.Hostname, "hostname", "H", "", "only impact traffic to these hostnames")
+	cmd.Flags().StringVar(&nFlag.IPFamily, "ip-family", "", "the IP family of the traffic to impact, supported: ipv4, ipv6. Default is both of them")
+	cmd.Flags().StringVarP(&nFlag.IPProtocol, "protocol", "p", "",
+		"only impact traffic using this IP protocol, supported: tcp, udp, icmp, all")
+	addDurationFlag(cmd, &nFlag.Duration)
+
+	return cmd
+}
+
+func NewNetworkReorderCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reorder",
+		Short: "reorder network packet",
+
+		Run: networkReorderCommandFunc,
+	}
+
+	cmd.Flags().StringVar(&nFlag.Reorder, "percent", "",
+		"percentage of packets to send immediately, the others are delayed by the latency (10 is 10%)")
+	cmd.Flags().StringVarP(&nFlag.ReorderCorrelation, "correlation", "c", "0", "correlation is percentage (10 is 10%)")
+	cmd.Flags().IntVar(&nFlag.Gap, "gap", 0,
+		"the packets are reordered every gap packets, 0 means reordering the packets randomly by the percent")
+	cmd.Flags().StringVarP(&nFlag.Latency, "latency", "l", "",
+		"the latency of the packets not reordered, it is required to reorder the packets, time units: ns, us (or µs), ms, s, m, h.")
+	cmd.Flags().StringVarP(&nFlag.Device, "device", "d", "", "the network interface to impact")
+	cmd.Flags().StringVar(&nFlag.Direction, "direction", "",
+		"the direction of the traffic to impact, supported: egress, ingress, both. Default is egress. "+
+			"The ingress traffic is redirected to an IFB device to be impacted")
+	cmd.Flags().StringVarP(&nFlag.EgressPort, "egress-port", "e", "",
+		"only impact egress traffic to these destination ports, use a ',' to separate or to indicate the range, such as 80, 8001:8010. "+
+			"It can only be used in conjunction with -p tcp or -p udp")
+	cmd.Flags().StringVarP(&nFlag.SourcePort, "source-port", "s", "",
+		"only impact egress traffic from these source ports, use a ',' to separate or to indicate the range, such as 80, 8001:8010. "+
+			"It can only be used in conjunction with -p tcp or -p udp")
+	cmd.Flags().StringVarP(&nFlag.IPAddress, "ip", "i", "", "only impact egress traffic to these IP addresses")
+	cmd.Flags().StringVarP(&nFlag.Hostname, "hostname", "H", "", "only impact traffic to these hostnames")
+	cmd.Flags().StringVar(&nFlag.IPFamily, "ip-family", "", "the IP family of the traffic to impact, supported: ipv4, ipv6. Default is both of them")
+	cmd.Flags().StringVarP(&nFlag.IPProtocol, "protocol", "p", "",
+		"only impact traffic using this IP protocol, supported: tcp, udp, icmp, all")
+	addDurationFlag(cmd, &nFlag.Duration)
+
+	return cmd
+}
+
+func NewNetworkNetemCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "netem",
+		Short: "combine delay, loss, duplicate, corrupt and reorder in one attack",
+
+		Run: networkNetemCommandFunc,
+	}
+
+	cmd.Flags().StringVarP(&nFlag.Latency, "latency", "l", "",
+		"delay egress time, time units: ns, us (or µs), ms, s, m, h.")
+	cmd.Flags().StringVarP(&nFlag.Jitter, "jitter", "j", "",
+		"jitter time, time units: ns, us (or µs), ms, s, m, h.")
+	cmd.Flags().StringVarP(&nFlag.Correlation, "correlation", "c", "0",
+		"correlation of delay is percentage (10 is 10%)")
+	cmd.Flags().StringVar(&nFlag.Loss, "loss", "", "percentage of packets to drop (10 is 10%)")
+	cmd.Flags().StringVar(&nFlag.LossCorrelation, "loss-correlation", "0",
+		"correlation of loss is percentage (10 is 10%)")
+	cmd.Flags().StringVar(&nFlag.Duplicate, "duplicate", "", "percentage of packets to duplicate (10 is 10%)")
+	cmd.Flags().StringVar(&nFlag.DuplicateCorrelation, "duplicate-correlation", "0",
+		"correlation of duplicate is percentage (10 is 10%)")
+	cmd.Flags().StringVar(&nFlag.Corrupt, "corrupt", "", "percentage of packets to corrupt (10 is 10%)")
+	cmd.Flags().StringVar(&nFlag.CorruptCorrelation, "corrupt-correlation", "0",
+		"correlation of corrupt is percentage (10 is 10%)")
+	cmd.Flags().StringVar(&nFlag.Reorder, "reorder", "",
+		"percentage of packets to send immediately, the others are delayed by the latency (10 is 10%). "+
+			"It can only be used in conjunction with --latency")
+	cmd.Flags().StringVar(&nFlag.ReorderCorrelation, "reorder-correlation", "0",
+		"correlation of reorder is percentage (10 is 10%)")
+	cmd.Flags().IntVar(&nFlag.Gap, "gap", 0,
+		"the packets are reordered every gap packets, 0 means reordering the packets randomly by the reorder percent")
+	cmd.Flags().StringVarP(&nFlag.Device, "device", "d", "", "the network interface to impact")
+	cmd.Flags().StringVar(&nFlag.Direction, "direction", "",
+		"the direction of the traffic to impact, supported: egress, ingress, both. Default is egress. "+
+			"The ingress traffic is redirected to an IFB device to be impacted")
 	cmd.Flags().StringVarP(&nFlag.EgressPort, "egress-port", "e", "",
 		"only impact egress traffic to these destination ports, use a ',' to separate or to indicate the range, such as 80, 8001:8010. "+
 			"It can only be used in conjunction with -p tcp or -p udp")
@@ -136,12 +316,116 @@ func NetworkDuplicateCommand() *cobra.Command {
 			"It can only be used in conjunction with -p tcp or -p udp")
 	cmd.Flags().StringVarP(&nFlag.IPAddress, "ip", "i", "", "only impact egress traffic to these IP addresses")
 	cmd.Flags().StringVarP(&nFlag.Hostname, "hostname", "H", "", "only impact traffic to these hostnames")
+	cmd.Flags().StringVar(&nFlag.IPFamily, "ip-family", "", "the IP family of the traffic to impact, supported: ipv4, ipv6. Default is both of them")
 	cmd.Flags().StringVarP(&nFlag.IPProtocol, "protocol", "p", "",
 		"only impact traffic using this IP protocol, supported: tcp, udp, icmp, all")
+	addDurationFlag(cmd, &nFlag.Duration)
 
 	return cmd
 }
 
+func NewNetworkProfileCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "apply a named preset of network condition, such as 3g, lte",
+
+		Run: networkProfileCommandFunc,
+	}
+
+	cmd.AddCommand(NewNetworkProfileListCommand())
+
+	cmd.PersistentFlags().StringVar(&conf.NetworkProfileDir, "profile-dir", "",
+		"the directory of the YAML files which define network profiles, "+
+			"default is the network-profiles directory next to chaosd")
+	cmd.Flags().StringVarP(&nFlag.Profile, "name", "n", "", "the name of network profile, "+
+		"use 'chaosd attack network profile list' to get the supported profiles")
+	cmd.Flags().StringVarP(&nFlag.Device, "device", "d", "", "the network interface to impact")
+	cmd.Flags().StringVar(&nFlag.Direction, "direction", "",
+		"the direction of the traffic to impact, supported: egress, ingress, both. Default is egress. "+
+			"The ingress traffic is redirected to an IFB device to be impacted")
+	cmd.Flags().StringVarP(&nFlag.EgressPort, "egress-port", "e", "",
+		"only impact egress traffic to these destination ports, use a ',' to separate or to indicate the range, such as 80, 8001:8010. "+
+			"It can only be used in conjunction with -p tcp or -p udp")
+	cmd.Flags().StringVarP(&nFlag.SourcePort, "source-port", "s", "",
+		"only impact egress traffic from these source ports, use a ',' to separate or to indicate the range, such as 80, 8001:8010. "+
+			"It can only be used in conjunction with -p tcp or -p udp")
+	cmd.Flags().StringVarP(&nFlag.IPAddress, "ip", "i", "", "only impact egress traffic to these IP addresses")
+	cmd.Flags().StringVarP(&nFlag.Hostname, "hostname", "H", "", "only impact traffic to these hostnames")
+	cmd.Flags().StringVar(&nFlag.IPFamily, "ip-family", "", "the IP family of the traffic to impact, supported: ipv4, ipv6. Default is both of them")
+	cmd.Flags().StringVarP(&nFlag.IPProtocol, "protocol", "p", "",
+		"only impact traffic using this IP protocol, supported: tcp, udp, icmp, all")
+	addDurationFlag(cmd, &nFlag.Duration)
+
+	return cmd
+}
+
+func NewNetworkProfileListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "list the built-in and user-defined network profiles",
+
+		Run: networkProfileListCommandFunc,
+	}
+}
+
+func networkProfileListCommandFunc(cmd *cobra.Command, args []string) {
+	mustLocal(cmd)
+
+	chaos := mustChaosdFromCmd(cmd, &conf)
+
+	profiles, err := chaos.NetworkProfiles()
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+
+	tw := tablewriter.NewWriter(os.Stdout)
+	tw.SetHeader([]string{"Name", "Description", "Condition", "Source"})
+	tw.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: false})
+	tw.SetAlignment(3)
+	tw.SetRowSeparator("-")
+	tw.SetCenterSeparator(" ")
+	tw.SetColumnSeparator(" ")
+
+	for _, p := range profiles {
+		tw.Append([]string{p.Name, p.Description, p.Summary(), p.Source})
+	}
+
+	tw.Render()
+}
+
+func networkProfileCommandFunc(cmd *cobra.Command, args []string) {
+	nFlag.Action = core.NetworkProfileAction
+
+	commonNetworkAttackFunc(cmd)
+}
+
+func networkNetemCommandFunc(cmd *cobra.Command, args []string) {
+	nFlag.Action = core.NetworkNetemAction
+	nFlag.SetDefaultForNetworkNetem()
+
+	commonNetworkAttackFunc(cmd)
+}
+
+func networkReorderCommandFunc(cmd *cobra.Command, args []string) {
+	nFlag.Action = core.NetworkReorderAction
+	nFlag.SetDefaultForNetworkReorder()
+
+	commonNetworkAttackFunc(cmd)
+}
+
+func networkBandwidthCommandFunc(cmd *cobra.Command, args []string) {
+	nFlag.Action = core.NetworkBandwidthAction
+
+	commonNetworkAttackFunc(cmd)
+}
+
+func networkPartitionCommandFunc(cmd *cobra.Command, args []string) {
+	nFlag.Action = core.NetworkPartitionAction
+	nFlag.SetDefaultForNetworkPartition()
+
+	commonNetworkAttackFunc(cmd)
+}
+
 func networkDuplicateCommandFunc(cmd *cobra.Command, args []string) {
 	nFlag.Action = core.NetworkDuplicateAction
 
@@ -173,11 +457,11 @@ func commonNetworkAttackFunc(cmd *cobra.Command) {
 		ExitWithError(ExitBadArgs, err)
 	}
 
-	chaos := mustChaosdFromCmd(cmd, &conf)
+	chaos := mustChaosdClientFromCmd(cmd, &conf)
 
 	uid, err := chaos.NetworkAttack(&nFlag)
 	if err != nil {
-		ExitWithError(ExitError, err)
+		ExitWithChaosdError(err)
 	}
 
 	NormalExit(fmt.Sprintf("Attack network successfully, uid: %s", uid))
@@ -15,6 +15,7 @@ package command
 
 import (
 	"fmt"
+	"strconv"
 	"syscall"
 
 	"github.com/spf13/cobra"
@@ -24,6 +25,12 @@ import (
 
 var pFlag core.ProcessCommand
 
+// processSignal is the name or the number of the signal sent by the process kill attack
+var processSignal string
+
+// processDryRun only lists the processes selected by the attack
+var processDryRun bool
+
 func NewProcessAttackCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "process <subcommand>",
@@ -35,6 +42,14 @@ func NewProcessAttackCommand() *cobra.Command {
 		NewProcessStopCommand(),
 	)
 
+	cmd.PersistentFlags().StringVar(&pFlag.Container, "container", "",
+		"the container whose processes are attacked, such as docker://<id> or containerd://<id>, "+
+			"the process ID is the ID in the container. Default is empty that means the host")
+	cmd.PersistentFlags().BoolVar(&pFlag.KillChildren, "kill-children", false,
+		"send the signal to all the descendants of the selected processes too, "+
+			"the children are killed before their parents, and the parents are stopped before their children")
+	cmd.PersistentFlags().BoolVar(&pFlag.KillChildren, "tree", false, "alias of --kill-children")
+
 	return cmd
 }
 
@@ -45,8 +60,22 @@ func NewProcessKillCommand() *cobra.Command {
 		Run:   processKillCommandFunc,
 	}
 
-	cmd.Flags().StringVarP(&pFlag.Process, "process", "p", "", "The process name or the process ID")
-	cmd.Flags().IntVarP(&pFlag.Signal, "single", "s", 9, "The signal number to send")
+	addProcessSelectorFlags(cmd)
+	cmd.Flags().StringVarP(&processSignal, "signal", "s", "SIGKILL",
+		"the name or the number of the signal to send, such as SIGTERM, HUP or 10. "+
+			"The processes received SIGSTOP, SIGTSTP, SIGTTIN or SIGTTOU can be recovered")
+	cmd.Flags().StringVar(&processSignal, "single", "SIGKILL", "the name or the number of the signal to send")
+	_ = cmd.Flags().MarkDeprecated("single", "use --signal instead")
+	cmd.Flags().BoolVar(&pFlag.Restart, "restart", false,
+		"restart the killed processes when the attack is recovered, with the command line, working directory, "+
+			"environment, user and resource limits captured before they are killed")
+	cmd.Flags().StringVar(&pFlag.Interval, "interval", "",
+		"send the signal repeatedly with the interval, the processes are selected again each time, "+
+			"so the new processes are attacked too. It requires the chaosd server, and lasts until --times or --duration is reached, "+
+			"or the attack is recovered. Default is empty that means the signal is sent once")
+	cmd.Flags().IntVar(&pFlag.Times, "times", 0,
+		"how many times the signal is sent with --interval, default is 0 that means until the attack is recovered")
+	addDurationFlag(cmd, &pFlag.Duration)
 
 	return cmd
 }
@@ -59,31 +88,81 @@ func NewProcessStopCommand() *cobra.Command {
 		Run: processStopCommandFunc,
 	}
 
-	cmd.Flags().StringVarP(&pFlag.Process, "process", "p", "", "The process name or the process ID")
+	addProcessSelectorFlags(cmd)
+	addDurationFlag(cmd, &pFlag.Duration)
 
 	return cmd
 }
 
+// addProcessSelectorFlags adds the flags selecting the processes to attack,
+// the processes matching all the specified selectors are attacked.
+func addProcessSelectorFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&pFlag.Process, "process", "p", "", "The process name or the process ID")
+	cmd.Flags().StringVar(&pFlag.Cmdline, "cmdline", "",
+		"the regular expression matched with the full command line of the process, such as 'java .*-jar worker.jar'")
+	cmd.Flags().StringVarP(&pFlag.User, "user", "u", "", "the name or the UID of the user owning the process")
+	cmd.Flags().IntVar(&pFlag.PPID, "ppid", 0, "the ID of the parent process")
+	cmd.Flags().StringVar(&pFlag.Cgroup, "cgroup", "",
+		"the cgroup path of the process, such as /system.slice/docker.service, the processes in the sub cgroups are matched too")
+	cmd.Flags().BoolVar(&pFlag.Newest, "newest", false,
+		"select the latest started process of the matched ones, or the latest started ones if --count or --percent is set")
+	cmd.Flags().BoolVar(&pFlag.Oldest, "oldest", false,
+		"select the earliest started process of the matched ones, or the earliest started ones if --count or --percent is set")
+	cmd.Flags().IntVar(&pFlag.Count, "count", 0,
+		"the number of the matched processes to select, they are selected randomly unless --newest or --oldest is set. "+
+			"Default is 0 that means all the matched processes")
+	cmd.Flags().IntVar(&pFlag.Percent, "percent", 0,
+		"the percent of the matched processes to select, rounded up, they are selected randomly unless --newest or --oldest is set")
+	cmd.Flags().BoolVar(&processDryRun, "dry-run", false, "list the selected processes without attacking them")
+}
+
 func processKillCommandFunc(cmd *cobra.Command, args []string) {
+	signal, err := core.ParseSignal(processSignal)
+	if err != nil {
+		ExitWithError(ExitBadArgs, err)
+	}
+
+	pFlag.Action = core.ProcessKillAction
+	pFlag.Signal = signal
 	processAttackF(cmd, &pFlag)
 }
 
 func processStopCommandFunc(cmd *cobra.Command, args []string) {
+	pFlag.Action = core.ProcessStopAction
 	pFlag.Signal = int(syscall.SIGSTOP)
 	processAttackF(cmd, &pFlag)
 }
 
 func processAttackF(cmd *cobra.Command, f *core.ProcessCommand) {
-	if err := pFlag.Validate(); err != nil {
+	if err := f.Validate(); err != nil {
 		ExitWithError(ExitBadArgs, err)
 	}
 
-	chaos := mustChaosdFromCmd(cmd, &conf)
+	// the signal is sent repeatedly by chaosd server, the local command exits after the first time
+	if f.Repeated() && !processDryRun {
+		mustRemoteClientFromCmd(cmd)
+	}
+
+	chaos := mustChaosdClientFromCmd(cmd, &conf)
+
+	if processDryRun {
+		processes, err := chaos.MatchProcesses(f)
+		if err != nil {
+			ExitWithChaosdError(err)
+		}
+
+		tw := newTableWriter([]string{"PID", "PPID", "UID", "Command"})
+		for _, p := range processes {
+			tw.Append([]string{strconv.Itoa(p.Pid), strconv.Itoa(p.PPid), strconv.Itoa(p.Uid), p.Cmdline})
+		}
+		tw.Render()
+		return
+	}
 
 	uid, err := chaos.ProcessAttack(f)
 	if err != nil {
-		ExitWithError(ExitError, err)
+		ExitWithChaosdError(err)
 	}
 
-	NormalExit(fmt.Sprintf("Attack process %s successfully, uid: %s", f.Process, uid))
+	NormalExit(fmt.Sprintf("Attack process %s successfully, uid: %s", f.Selector(), uid))
 }
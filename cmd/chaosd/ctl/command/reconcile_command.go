@@ -0,0 +1,65 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+var rcFlag core.ReconcileCommand
+
+func NewReconcileCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reconcile",
+		Short: "Reconcile the experiments left by a crashed chaosd with the state of the host",
+		Run:   reconcileCommandFunc,
+	}
+
+	cmd.Flags().BoolVar(&rcFlag.DryRun, "dry-run", false, "only print the operations without performing them")
+
+	return cmd
+}
+
+func reconcileCommandFunc(cmd *cobra.Command, args []string) {
+	// the experiments are reconciled by the chaosd server when it starts
+	mustLocal(cmd)
+
+	chaos := mustChaosdFromCmd(cmd, &conf)
+
+	results, err := chaos.Reconcile(&rcFlag)
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+
+	tw := tablewriter.NewWriter(os.Stdout)
+	tw.SetHeader([]string{"UID", "Kind", "Action", "Status", "Operation", "Message", "Error"})
+	tw.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: false})
+	tw.SetAlignment(3)
+	tw.SetRowSeparator("-")
+	tw.SetCenterSeparator(" ")
+	tw.SetColumnSeparator(" ")
+
+	for _, result := range results {
+		tw.Append([]string{
+			result.Uid, result.Kind, result.Action, result.Status, result.Operation, result.Message, result.Error,
+		})
+	}
+
+	tw.Render()
+}
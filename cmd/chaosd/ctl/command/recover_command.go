@@ -17,8 +17,6 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-
-	"github.com/chaos-mesh/chaosd/pkg/server/utils"
 )
 
 func NewRecoverCommand() *cobra.Command {
@@ -41,12 +39,10 @@ func recoverCommandF(cmd *cobra.Command, args []string) {
 	}
 	uid := args[0]
 
-	expStore := mustExpStoreFromCmd()
-	chaos := mustChaosdFromCmd(cmd, &conf)
+	chaos := mustChaosdClientFromCmd(cmd, &conf)
 
-	err := utils.RecoverExp(expStore, chaos, uid)
-	if err != nil {
-		ExitWithError(ExitError, err)
+	if err := chaos.RecoverAttack(uid); err != nil {
+		ExitWithChaosdError(err)
 	}
 
 	NormalExit(fmt.Sprintf("Recover %s successfully", uid))
@@ -0,0 +1,182 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+// scheduleFile is the file of the schedule spec, - means the standard input
+var scheduleFile string
+
+func NewScheduleCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schedule <subcommand>",
+		Short: "Create experiments periodically on chaosd server by cron schedules",
+	}
+
+	cmd.AddCommand(
+		NewScheduleCreateCommand(),
+		NewScheduleListCommand(),
+		NewSchedulePauseCommand(),
+		NewScheduleResumeCommand(),
+		NewScheduleDeleteCommand(),
+	)
+
+	return cmd
+}
+
+func NewScheduleCreateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create -f FILENAME",
+		Short: "Create the schedule of the spec in YAML or JSON",
+		Run:   scheduleCreateCommandFunc,
+	}
+
+	cmd.Flags().StringVarP(&scheduleFile, "filename", "f", "", "the file of the schedule spec, - means the standard input")
+
+	return cmd
+}
+
+func NewScheduleListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list [UID]",
+		Short: "List the schedules, the experiments created by the schedule are listed if the UID is provided",
+		Run:   scheduleListCommandFunc,
+	}
+}
+
+func NewSchedulePauseCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "pause UID",
+		Short: "Pause the schedule, the running experiments are not recovered",
+		Args:  cobra.ExactArgs(1),
+		Run:   schedulePauseCommandFunc,
+	}
+}
+
+func NewScheduleResumeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume UID",
+		Short: "Resume the paused schedule, the runs missed while it is paused are skipped",
+		Args:  cobra.ExactArgs(1),
+		Run:   scheduleResumeCommandFunc,
+	}
+}
+
+func NewScheduleDeleteCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete UID",
+		Short: "Delete the schedule and recover its running experiments",
+		Args:  cobra.ExactArgs(1),
+		Run:   scheduleDeleteCommandFunc,
+	}
+}
+
+func scheduleCreateCommandFunc(cmd *cobra.Command, args []string) {
+	if len(scheduleFile) == 0 {
+		ExitWithMsg(ExitBadArgs, "Error: the file of the schedule spec is required")
+	}
+
+	spec, err := core.ParseScheduleSpec(mustReadFile(scheduleFile))
+	if err != nil {
+		ExitWithError(ExitBadArgs, err)
+	}
+	spec.SetDefault()
+
+	if err := spec.Validate(); err != nil {
+		ExitWithError(ExitBadArgs, err)
+	}
+
+	cli := mustRemoteClientFromCmd(cmd)
+
+	status, err := cli.CreateSchedule(context.Background(), spec)
+	if err != nil {
+		ExitWithChaosdError(err)
+	}
+
+	NormalExit(fmt.Sprintf("Create schedule %s successfully, uid: %s", status.Name, status.Uid))
+}
+
+func scheduleListCommandFunc(cmd *cobra.Command, args []string) {
+	cli := mustRemoteClientFromCmd(cmd)
+
+	if len(args) == 0 {
+		schedules, err := cli.ListSchedules(context.Background())
+		if err != nil {
+			ExitWithChaosdError(err)
+		}
+
+		tw := newTableWriter([]string{"UID", "Name", "Paused", "Last Schedule Time", "Next Schedule Time"})
+		for _, schedule := range schedules {
+			tw.Append([]string{
+				schedule.Uid, schedule.Name, strconv.FormatBool(schedule.Paused),
+				formatTime(schedule.LastScheduleTime), formatTime(schedule.NextScheduleTime),
+			})
+		}
+		tw.Render()
+		return
+	}
+
+	status, err := cli.GetSchedule(context.Background(), args[0])
+	if err != nil {
+		ExitWithChaosdError(err)
+	}
+
+	fmt.Printf("Schedule %s (%s), paused: %t, next schedule time: %s\n",
+		status.Name, status.Uid, status.Paused, formatTime(status.NextScheduleTime))
+
+	tw := newTableWriter([]string{"UID", "Kind", "Action", "Status", "Create Time", "Error"})
+	for _, exp := range status.Experiments {
+		tw.Append([]string{exp.Uid, exp.Kind, exp.Action, exp.Status, exp.CreatedAt.Format(time.RFC3339), exp.Message})
+	}
+	tw.Render()
+}
+
+func schedulePauseCommandFunc(cmd *cobra.Command, args []string) {
+	cli := mustRemoteClientFromCmd(cmd)
+
+	if _, err := cli.PauseSchedule(context.Background(), args[0]); err != nil {
+		ExitWithChaosdError(err)
+	}
+
+	NormalExit(fmt.Sprintf("Pause schedule %s successfully", args[0]))
+}
+
+func scheduleResumeCommandFunc(cmd *cobra.Command, args []string) {
+	cli := mustRemoteClientFromCmd(cmd)
+
+	if _, err := cli.ResumeSchedule(context.Background(), args[0]); err != nil {
+		ExitWithChaosdError(err)
+	}
+
+	NormalExit(fmt.Sprintf("Resume schedule %s successfully", args[0]))
+}
+
+func scheduleDeleteCommandFunc(cmd *cobra.Command, args []string) {
+	cli := mustRemoteClientFromCmd(cmd)
+
+	if _, err := cli.DeleteSchedule(context.Background(), args[0]); err != nil {
+		ExitWithChaosdError(err)
+	}
+
+	NormalExit(fmt.Sprintf("Delete schedule %s successfully", args[0]))
+}
@@ -15,6 +15,7 @@ package command
 
 import (
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/olekukonko/tablewriter"
@@ -36,7 +37,7 @@ func NewSearchCommand() *cobra.Command {
 	cmd.Flags().StringVarP(&sFlag.Status, "status", "s", "", "attack status, "+
 		"supported value: created, success, error, destroyed, revoked")
 	cmd.Flags().StringVarP(&sFlag.Kind, "kind", "k", "", "attack kind, "+
-		"supported value: network, process")
+		"supported value: network, process, stress")
 	cmd.Flags().Uint32VarP(&sFlag.Offset, "offset", "o", 0, "starting to search attacks from offset")
 	cmd.Flags().Uint32VarP(&sFlag.Limit, "limit", "l", 0, "limit the count of attacks")
 	cmd.Flags().BoolVar(&sFlag.Asc, "asc", false, "order by CreateTime, "+
@@ -54,15 +55,15 @@ func searchCommandFunc(cmd *cobra.Command, args []string) {
 		ExitWithError(ExitBadArgs, err)
 	}
 
-	chaos := mustChaosdFromCmd(cmd, &conf)
+	chaos := mustChaosdClientFromCmd(cmd, &conf)
 
 	exps, err := chaos.Search(&sFlag)
 	if err != nil {
-		ExitWithError(ExitError, err)
+		ExitWithChaosdError(err)
 	}
 
 	tw := tablewriter.NewWriter(os.Stdout)
-	tw.SetHeader([]string{"UID", "Kind", "Action", "Status", "Create Time", "Configuration"})
+	tw.SetHeader([]string{"UID", "Kind", "Action", "Status", "Recoverable", "Create Time", "Configuration"})
 	tw.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: false})
 	tw.SetAlignment(3)
 	tw.SetRowSeparator("-")
@@ -71,7 +72,7 @@ func searchCommandFunc(cmd *cobra.Command, args []string) {
 
 	for _, exp := range exps {
 		tw.Append([]string{
-			exp.Uid, exp.Kind, exp.Action, exp.Status, exp.CreatedAt.Format(time.RFC3339), exp.RecoverCommand,
+			exp.Uid, exp.Kind, exp.Action, exp.Status, strconv.FormatBool(exp.Recoverable), exp.CreatedAt.Format(time.RFC3339), exp.RecoverCommand,
 		})
 	}
 
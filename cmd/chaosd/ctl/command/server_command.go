@@ -37,13 +37,20 @@ func NewServerCommand() *cobra.Command {
 	cmd.Flags().BoolVar(&conf.EnablePprof, "enable-pprof", true, "enable pprof")
 	cmd.Flags().IntVar(&conf.PprofPort, "pprof-port", 31766, "listen port of the pprof server")
 	cmd.Flags().StringVarP(&conf.Platform, "platform", "f", "local", "platform to deploy, default: local, supported platform: local, kubernetes")
+	cmd.Flags().StringVar(&conf.NetworkProfileDir, "network-profile-dir", "",
+		"the directory of the YAML files which define network profiles, "+
+			"default is the network-profiles directory next to chaosd")
+	cmd.Flags().StringVar(&conf.MemoryReserve, "memory-reserve", config.DefaultMemoryReserve,
+		"the memory which the memory stress attacks must leave available for the host, such as 1g or 5% of the total memory, "+
+			"the attack is refused if its size exceeds the available memory minus the reserve")
 
 	return cmd
 }
 
 var conf = config.Config{
-	Platform: config.LocalPlatform,
-	Runtime:  "docker",
+	Platform:      config.LocalPlatform,
+	Runtime:       "docker",
+	MemoryReserve: config.DefaultMemoryReserve,
 }
 
 func serverCommandFunc(cmd *cobra.Command, args []string) {
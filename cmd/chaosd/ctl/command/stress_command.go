@@ -32,8 +32,16 @@ func NewStressAttackCommand() *cobra.Command {
 	cmd.AddCommand(
 		NewStressCPUCommand(),
 		NewStressMemCommand(),
+		NewStressIOCommand(),
 	)
 
+	cmd.PersistentFlags().StringVar(&stFlag.Container, "container", "",
+		"the container which the stressors run in, such as docker://<id> or containerd://<id>, "+
+			"the stressors join the namespaces and cgroups of the container. Default is empty that means the host")
+	cmd.PersistentFlags().StringVar(&stFlag.Engine, "engine", core.StressEngineAuto,
+		"the engine which runs the stressors, supported: stress-ng, builtin, auto. "+
+			"auto runs the stressors by stress-ng if it is installed, otherwise the builtin stressors of chaosd")
+
 	return cmd
 }
 
@@ -47,6 +55,13 @@ func NewStressCPUCommand() *cobra.Command {
 	cmd.Flags().IntVarP(&stFlag.Load, "load", "l", 10, "Load specifies P percent loading per CPU worker. 0 is effectively a sleep (no load) and 100 is full loading.")
 	cmd.Flags().IntVarP(&stFlag.Workers, "workers", "w", 1, "Workers specifies N workers to apply the stressor.")
 	cmd.Flags().StringSliceVarP(&stFlag.Options, "options", "o", []string{}, "extend stress-ng options.")
+	addDurationFlag(cmd, &stFlag.Duration)
+
+	cmd.Flags().StringVar(&stFlag.CPUs, "cpus", "",
+		"the CPUs which the workers are pinned to, such as 2-3 or 0,2,4. Default is empty that means all the CPUs")
+	cmd.Flags().StringVar(&stFlag.Cgroup, "cgroup", "",
+		"the cgroup directory which the workers are moved into, both cgroup v1 and v2 are supported, "+
+			"such as /sys/fs/cgroup/cpu/app or /sys/fs/cgroup/app")
 
 	return cmd
 }
@@ -60,7 +75,38 @@ func NewStressMemCommand() *cobra.Command {
 	}
 
 	cmd.Flags().IntVarP(&stFlag.Workers, "workers", "w", 1, "Workers specifies N workers to apply the stressor.")
+	cmd.Flags().StringVar(&stFlag.Size, "size", "",
+		"the memory consumed by all the workers, such as 6g or 80% of the available memory. "+
+			"Default is empty that means the workers grow the heap until they are killed by OOM")
+	cmd.Flags().BoolVar(&stFlag.Hang, "hang", false, "hold the memory after it is allocated, it requires --size")
+	cmd.Flags().BoolVar(&stFlag.Keep, "keep", false,
+		"keep rewriting the memory instead of unmapping and mapping it again, it requires --size")
 	cmd.Flags().StringSliceVarP(&stFlag.Options, "options", "o", []string{}, "extend stress-ng options.")
+	addDurationFlag(cmd, &stFlag.Duration)
+
+	return cmd
+}
+
+func NewStressIOCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "io [options]",
+		Short: "continuously stress disk I/O by writing and reading temporary files",
+
+		Run: stressIOCommandFunc,
+	}
+
+	cmd.Flags().IntVarP(&stFlag.Workers, "workers", "w", 1, "Workers specifies N workers to apply the stressor.")
+	cmd.Flags().StringVar(&stFlag.Size, "size", "",
+		"the bytes written by each worker, such as 512m, 1g or 10% of the free space. Default is decided by stress-ng")
+	cmd.Flags().StringVar(&stFlag.Mode, "mode", core.StressIOWrite,
+		"the read and write mix, write writes files sequentially, readwrite writes and then reads files sequentially, "+
+			"mix mixes the sequential, random and memory mapped reads and writes")
+	cmd.Flags().StringVar(&stFlag.Path, "path", "",
+		"the directory where the temporary files are written, so the load lands on its filesystem. Default is the temporary directory")
+	cmd.Flags().StringVar(&stFlag.Device, "device", "",
+		"the block device where the temporary files are written, such as /dev/sdb, the files are written in its mount point")
+	cmd.Flags().StringSliceVarP(&stFlag.Options, "options", "o", []string{}, "extend stress-ng options.")
+	addDurationFlag(cmd, &stFlag.Duration)
 
 	return cmd
 }
@@ -75,16 +121,21 @@ func stressMemCommandFunc(cmd *cobra.Command, args []string) {
 	stressAttackF(cmd, &stFlag)
 }
 
+func stressIOCommandFunc(cmd *cobra.Command, args []string) {
+	stFlag.Action = core.StressIOAction
+	stressAttackF(cmd, &stFlag)
+}
+
 func stressAttackF(cmd *cobra.Command, s *core.StressCommand) {
 	if err := stFlag.Validate(); err != nil {
 		ExitWithError(ExitBadArgs, err)
 	}
 
-	chaos := mustChaosdFromCmd(cmd, &conf)
+	chaos := mustChaosdClientFromCmd(cmd, &conf)
 
 	uid, err := chaos.StressAttack(s)
 	if err != nil {
-		ExitWithError(ExitError, err)
+		ExitWithChaosdError(err)
 	}
 
 	NormalExit(fmt.Sprintf("Attack stress %s successfully, uid: %s", s.Action, uid))
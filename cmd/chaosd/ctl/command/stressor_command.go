@@ -0,0 +1,72 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chaos-mesh/chaosd/pkg/stress"
+)
+
+var (
+	stressorFlag    stress.Options
+	stressorTimeout int
+)
+
+// NewStressorCommand returns the hidden command which runs the builtin stressors,
+// it is started by chaosd server as the child process of the stress attack with the builtin engine.
+func NewStressorCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    stress.CommandName,
+		Short:  "Run the builtin stressors",
+		Hidden: true,
+		Run:    stressorCommandFunc,
+	}
+
+	cmd.Flags().StringVar(&stressorFlag.Action, "action", "", "the stressors to run, supported: cpu, mem, io")
+	cmd.Flags().IntVar(&stressorFlag.Workers, "workers", 1, "the number of workers, 0 means one worker per CPU")
+	cmd.Flags().IntVar(&stressorFlag.Load, "load", 100, "the percent of the CPU time used by each cpu worker")
+	cmd.Flags().Uint64Var(&stressorFlag.Bytes, "bytes", 0, "the memory allocated or the file written by each worker")
+	cmd.Flags().BoolVar(&stressorFlag.Hang, "hang", false, "hold the memory after it is allocated")
+	cmd.Flags().BoolVar(&stressorFlag.Keep, "keep", false, "keep rewriting the memory")
+	cmd.Flags().StringVar(&stressorFlag.Mode, "mode", "", "the read and write mix of the io workers")
+	cmd.Flags().StringVar(&stressorFlag.TempDir, "temp-path", "", "the directory of the files written by the io workers")
+	cmd.Flags().IntVar(&stressorTimeout, "timeout", 0, "stop the stressors after the seconds, 0 means no timeout")
+
+	return cmd
+}
+
+func stressorCommandFunc(cmd *cobra.Command, args []string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the stressors are recovered by SIGKILL, SIGTERM and SIGINT stop them gracefully
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		<-sigCh
+		cancel()
+	}()
+
+	stressorFlag.Timeout = time.Duration(stressorTimeout) * time.Second
+	if err := stress.Run(ctx, &stressorFlag); err != nil {
+		ExitWithError(ExitError, err)
+	}
+}
@@ -27,16 +27,25 @@ import (
 	"github.com/chaos-mesh/chaosd/pkg/store/dbstore"
 	"github.com/chaos-mesh/chaosd/pkg/store/experiment"
 	"github.com/chaos-mesh/chaosd/pkg/store/network"
+	"github.com/chaos-mesh/chaosd/pkg/store/process"
+	"github.com/chaos-mesh/chaosd/pkg/store/timer"
 )
 
 func mustChaosdFromCmd(cmd *cobra.Command, conf *config.Config) *chaosd.Server {
+	crClient := crclient.NewNodeCRClient(os.Getpid())
+
 	return chaosd.NewServer(
 		conf,
 		mustExpStoreFromCmd(),
 		mustIPSetRuleStoreFromCmd(),
 		mustIptablesRuleStoreFromCmd(),
 		mustTCRuleStoreFromCmd(),
-		chaosdaemon.NewDaemonServerWithCRClient(crclient.NewNodeCRClient(os.Getpid())))
+		mustIFBRuleStoreFromCmd(),
+		mustTimerStoreFromCmd(),
+		mustProcessEventStoreFromCmd(),
+		mustProcessSnapshotStoreFromCmd(),
+		chaosdaemon.NewDaemonServerWithCRClient(crClient),
+		crClient)
 }
 
 func mustExpStoreFromCmd() core.ExperimentStore {
@@ -74,3 +83,46 @@ func mustIptablesRuleStoreFromCmd() core.IptablesRuleStore {
 
 	return network.NewIptablesRuleStore(db)
 }
+
+func mustIFBRuleStoreFromCmd() core.IFBRuleStore {
+	db, err := dbstore.NewDBStore()
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+
+	return network.NewIFBRuleStore(db)
+}
+
+func mustTimerStoreFromCmd() core.TimerStore {
+	db, err := dbstore.NewDBStore()
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+
+	return timer.NewStore(db)
+}
+
+func mustProcessEventStoreFromCmd() core.ProcessEventStore {
+	db, err := dbstore.NewDBStore()
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+
+	return process.NewEventStore(db)
+}
+
+func mustProcessSnapshotStoreFromCmd() core.ProcessSnapshotStore {
+	db, err := dbstore.NewDBStore()
+	if err != nil {
+		ExitWithError(ExitError, err)
+	}
+
+	return process.NewSnapshotStore(db)
+}
+
+// addDurationFlag adds the duration flag of the attack, after which chaosd server recovers the attack automatically
+func addDurationFlag(cmd *cobra.Command, duration *string) {
+	cmd.Flags().StringVar(duration, "duration", "",
+		"work duration of the attack, the attack will be recovered automatically by chaosd server after the duration, "+
+			"time units: ns, us (or µs), ms, s, m, h. Default is empty that means the attack will not be recovered automatically")
+}
@@ -0,0 +1,169 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+// workflowFile is the file of the workflow spec, - means the standard input
+var workflowFile string
+
+func NewWorkflowCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "workflow <subcommand>",
+		Short: "Run multi-step chaos workflows on chaosd server",
+	}
+
+	cmd.AddCommand(
+		NewWorkflowRunCommand(),
+		NewWorkflowStatusCommand(),
+		NewWorkflowAbortCommand(),
+	)
+
+	return cmd
+}
+
+func NewWorkflowRunCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run -f FILENAME",
+		Short: "Run the workflow of the spec in YAML or JSON",
+		Run:   workflowRunCommandFunc,
+	}
+
+	cmd.Flags().StringVarP(&workflowFile, "filename", "f", "", "the file of the workflow spec, - means the standard input")
+
+	return cmd
+}
+
+func NewWorkflowStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status [UID]",
+		Short: "Show the status of the workflow and its steps, all the workflows are listed if the UID is not provided",
+		Run:   workflowStatusCommandFunc,
+	}
+}
+
+func NewWorkflowAbortCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "abort UID",
+		Short: "Abort the running workflow, the experiments created by it are recovered in reverse order",
+		Args:  cobra.ExactArgs(1),
+		Run:   workflowAbortCommandFunc,
+	}
+}
+
+func workflowRunCommandFunc(cmd *cobra.Command, args []string) {
+	if len(workflowFile) == 0 {
+		ExitWithMsg(ExitBadArgs, "Error: the file of the workflow spec is required")
+	}
+
+	spec, err := core.ParseWorkflowSpec(mustReadFile(workflowFile))
+	if err != nil {
+		ExitWithError(ExitBadArgs, err)
+	}
+
+	if err := spec.Validate(); err != nil {
+		ExitWithError(ExitBadArgs, err)
+	}
+
+	cli := mustRemoteClientFromCmd(cmd)
+
+	status, err := cli.RunWorkflow(context.Background(), spec)
+	if err != nil {
+		ExitWithChaosdError(err)
+	}
+
+	NormalExit(fmt.Sprintf("Run workflow %s successfully, uid: %s", status.Name, status.Uid))
+}
+
+func workflowStatusCommandFunc(cmd *cobra.Command, args []string) {
+	cli := mustRemoteClientFromCmd(cmd)
+
+	if len(args) == 0 {
+		workflows, err := cli.ListWorkflows(context.Background())
+		if err != nil {
+			ExitWithChaosdError(err)
+		}
+
+		tw := newTableWriter([]string{"UID", "Name", "Status", "Create Time", "Error"})
+		for _, wf := range workflows {
+			tw.Append([]string{wf.Uid, wf.Name, wf.Status, wf.CreatedAt.Format(time.RFC3339), wf.Message})
+		}
+		tw.Render()
+		return
+	}
+
+	status, err := cli.GetWorkflow(context.Background(), args[0])
+	if err != nil {
+		ExitWithChaosdError(err)
+	}
+
+	printWorkflowStatus(status)
+}
+
+func workflowAbortCommandFunc(cmd *cobra.Command, args []string) {
+	cli := mustRemoteClientFromCmd(cmd)
+
+	status, err := cli.AbortWorkflow(context.Background(), args[0])
+	if err != nil {
+		ExitWithChaosdError(err)
+	}
+
+	printWorkflowStatus(status)
+}
+
+func printWorkflowStatus(status *core.WorkflowStatus) {
+	fmt.Printf("Workflow %s (%s) is %s\n", status.Name, status.Uid, status.Status)
+	if len(status.Message) > 0 {
+		fmt.Printf("Error: %s\n", status.Message)
+	}
+
+	tw := newTableWriter([]string{"Step", "Type", "Status", "Experiment", "Start Time", "Finish Time", "Error"})
+	for _, node := range status.Nodes {
+		tw.Append([]string{
+			node.Path, node.Type, node.Status, node.Experiment,
+			formatTime(node.StartedAt), formatTime(node.FinishedAt), node.Message,
+		})
+	}
+	tw.Render()
+}
+
+func newTableWriter(header []string) *tablewriter.Table {
+	tw := tablewriter.NewWriter(os.Stdout)
+	tw.SetHeader(header)
+	tw.SetBorders(tablewriter.Border{Left: false, Top: false, Right: false, Bottom: false})
+	tw.SetAlignment(3)
+	tw.SetRowSeparator("-")
+	tw.SetCenterSeparator(" ")
+	tw.SetColumnSeparator(" ")
+
+	return tw
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
@@ -30,9 +30,17 @@ func init() {
 		command.NewServerCommand(),
 		command.NewAttackCommand(),
 		command.NewRecoverCommand(),
+		command.NewApplyCommand(),
+		command.NewDeleteCommand(),
+		command.NewWorkflowCommand(),
+		command.NewScheduleCommand(),
 		command.NewSearchCommand(),
+		command.NewReconcileCommand(),
 		command.NewVersionCommand(),
+		command.NewStressorCommand(),
 	)
+
+	command.AddServerFlag(rootCmd)
 }
 
 // Execute execs Command
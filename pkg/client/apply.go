@@ -0,0 +1,56 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+const applyPath = "api/apply"
+
+// ApplyExperiment creates the experiment of the spec. It is retried like the idempotent requests,
+// since the experiment is not created twice for the spec with the same name.
+func (c *Client) ApplyExperiment(ctx context.Context, spec *core.ExperimentSpec) (*core.ApplyResult, error) {
+	result := &core.ApplyResult{}
+	if err := c.doJSON(ctx, request{
+		method:     http.MethodPost,
+		path:       applyPath,
+		idempotent: true,
+		opts:       []BodyOption{withJsonBody([]byte(spec.String()))},
+	}, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DeleteExperiment recovers the running experiment created by applying the spec of the name,
+// an *APIError with status 404 is returned if it is not found.
+func (c *Client) DeleteExperiment(ctx context.Context, name string) (*core.ApplyResult, error) {
+	result := &core.ApplyResult{}
+	if err := c.doJSON(ctx, request{
+		method:     http.MethodDelete,
+		path:       fmt.Sprintf("%s/%s", applyPath, url.PathEscape(name)),
+		idempotent: true,
+	}, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -14,6 +14,7 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -25,33 +26,77 @@ import (
 )
 
 const (
-	processAttack = "api/attack/process"
+	attackPath        = "api/attack"
+	processAttackPath = "api/attack/process"
+	processDryRunPath = "api/attack/process/dry-run"
+	networkAttackPath = "api/attack/network"
+	stressAttackPath  = "api/attack/stress"
 )
 
-func (c *Client) CreateProcessAttack(attack *core.ProcessCommand) (*utils.Response, *utils.APIError, error) {
+// CreateProcessAttack creates a process attack, the uid of the experiment is in the response.
+func (c *Client) CreateProcessAttack(ctx context.Context, attack *core.ProcessCommand) (*utils.Response, error) {
+	return c.createAttack(ctx, processAttackPath, attack)
+}
+
+// MatchProcesses returns the processes which will be attacked by the process attack without attacking them.
+func (c *Client) MatchProcesses(ctx context.Context, attack *core.ProcessCommand) ([]*core.ProcessInfo, error) {
 	a, err := json.Marshal(attack)
 	if err != nil {
-		return nil, nil, errors.WithStack(err)
+		return nil, errors.WithStack(err)
 	}
 
-	url := fmt.Sprintf("%s/%s", c.cfg.Addr, processAttack)
-	data, apiErr, err := doRequest(c.client, url, http.MethodPost, withJsonBody(a))
-	if err != nil {
-		return nil, nil, errors.WithStack(err)
+	processes := make([]*core.ProcessInfo, 0)
+	if err := c.doJSON(ctx, request{
+		method:     http.MethodPost,
+		path:       processDryRunPath,
+		opts:       []BodyOption{withJsonBody(a)},
+		idempotent: true,
+	}, &processes); err != nil {
+		return nil, err
+	}
+
+	return processes, nil
+}
+
+// CreateNetworkAttack creates a network attack, the uid of the experiment is in the response.
+func (c *Client) CreateNetworkAttack(ctx context.Context, attack *core.NetworkCommand) (*utils.Response, error) {
+	return c.createAttack(ctx, networkAttackPath, attack)
+}
+
+// CreateStressAttack creates a stress attack, the uid of the experiment is in the response.
+func (c *Client) CreateStressAttack(ctx context.Context, attack *core.StressCommand) (*utils.Response, error) {
+	return c.createAttack(ctx, stressAttackPath, attack)
+}
+
+// RecoverAttack recovers the experiment of the uid
+func (c *Client) RecoverAttack(ctx context.Context, uid string) (*utils.Response, error) {
+	resp := &utils.Response{}
+	if err := c.doJSON(ctx, request{
+		method:     http.MethodDelete,
+		path:       fmt.Sprintf("%s/%s", attackPath, uid),
+		idempotent: true,
+	}, resp); err != nil {
+		return nil, err
 	}
 
-	if apiErr != nil {
-		aerr := &utils.APIError{}
-		if err := json.Unmarshal(apiErr, aerr); err != nil {
-			return nil, nil, errors.WithStack(err)
-		}
-		return nil, aerr, nil
+	return resp, nil
+}
+
+// createAttack is not retried, since the attack may be applied twice if the response is lost
+func (c *Client) createAttack(ctx context.Context, path string, attack interface{}) (*utils.Response, error) {
+	a, err := json.Marshal(attack)
+	if err != nil {
+		return nil, errors.WithStack(err)
 	}
 
 	resp := &utils.Response{}
-	if err := json.Unmarshal(data, resp); err != nil {
-		return nil, nil, errors.WithStack(err)
+	if err := c.doJSON(ctx, request{
+		method: http.MethodPost,
+		path:   path,
+		opts:   []BodyOption{withJsonBody(a)},
+	}, resp); err != nil {
+		return nil, err
 	}
 
-	return resp, nil, nil
+	return resp, nil
 }
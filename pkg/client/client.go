@@ -15,6 +15,14 @@ package client
 
 import (
 	"net/http"
+	"time"
+)
+
+const (
+	// defaultRetryBackoff is the backoff before the first retry if it is not configured
+	defaultRetryBackoff = 100 * time.Millisecond
+	// maxRetryBackoff is the max backoff between two retries
+	maxRetryBackoff = 5 * time.Second
 )
 
 // Client is used to communicate with the chaosd
@@ -25,13 +33,32 @@ type Client struct {
 
 // Config defines for chaosd client
 type Config struct {
+	// Addr is the address of chaosd server, such as http://127.0.0.1:31767
 	Addr string
+	// Timeout is the timeout of every request, zero means no timeout
+	Timeout time.Duration
+	// MaxRetries is the max times to retry the idempotent requests,
+	// the request is retried when the server is unreachable or unavailable.
+	MaxRetries int
+	// RetryBackoff is the backoff before the first retry, it is doubled after every retry
+	RetryBackoff time.Duration
+	// HTTPClient is used to send the requests, http.DefaultClient is used if it is nil
+	HTTPClient *http.Client
 }
 
 // NewClient creates a new chaosd client from a given address
 func NewClient(cfg Config) *Client {
+	cli := cfg.HTTPClient
+	if cli == nil {
+		cli = http.DefaultClient
+	}
+
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = defaultRetryBackoff
+	}
+
 	return &Client{
 		cfg:    cfg,
-		client: http.DefaultClient,
+		client: cli,
 	}
 }
@@ -0,0 +1,476 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/chaos-mesh/chaosd/pkg/config"
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd/chaosdtest"
+	"github.com/chaos-mesh/chaosd/pkg/server/httpserver"
+	"github.com/chaos-mesh/chaosd/pkg/server/scheduler"
+	"github.com/chaos-mesh/chaosd/pkg/server/workflow"
+	"github.com/chaos-mesh/chaosd/pkg/store/experiment"
+	"github.com/chaos-mesh/chaosd/pkg/store/schedule"
+	wfstore "github.com/chaos-mesh/chaosd/pkg/store/workflow"
+)
+
+// newTestHandler returns the handler of the real chaosd API, the experiments are stored in a temporary DB.
+func newTestHandler(t *testing.T) http.Handler {
+	db := chaosdtest.NewDB(t)
+
+	conf := &config.Config{Platform: config.LocalPlatform, Runtime: "docker"}
+	exp := experiment.NewStore(db)
+	chaos := chaosdtest.NewServer(db)
+
+	engine := workflow.NewEngine(exp, wfstore.NewStore(db), chaos)
+	sched := scheduler.NewScheduler(exp, schedule.NewStore(db), chaos)
+
+	lc := fxtest.NewLifecycle(t)
+	scheduler.Register(sched, lc)
+	lc.RequireStart()
+	t.Cleanup(func() { lc.RequireStop() })
+
+	return httpserver.NewServer(conf, chaos, exp, engine, sched).Handler()
+}
+
+func TestProcessAttack(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(newTestHandler(t))
+	defer server.Close()
+
+	cli := NewClient(Config{Addr: server.URL, Timeout: 10 * time.Second})
+	pid := chaosdtest.StartSleepProcess(t)
+
+	resp, err := cli.CreateProcessAttack(context.Background(), &core.ProcessCommand{
+		Action:  core.ProcessStopAction,
+		Process: strconv.Itoa(pid),
+		Signal:  int(syscall.SIGSTOP),
+	})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(resp.UID).ShouldNot(BeEmpty())
+	g.Eventually(func() string { return chaosdtest.ProcessStatus(pid) }).Should(Equal("T"))
+
+	detail, err := cli.GetExperiment(context.Background(), resp.UID)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(detail.Recoverable).To(BeTrue())
+
+	recoverResp, err := cli.RecoverAttack(context.Background(), resp.UID)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(recoverResp.UID).To(Equal(resp.UID))
+	g.Eventually(func() string { return chaosdtest.ProcessStatus(pid) }).ShouldNot(Equal("T"))
+
+	// the experiment can not be recovered twice
+	_, err = cli.RecoverAttack(context.Background(), resp.UID)
+	apiErr, ok := AsAPIError(err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(apiErr.StatusCode).To(Equal(http.StatusInternalServerError))
+	g.Expect(apiErr.Message).To(ContainSubstring("can not recover destroyed experiment"))
+}
+
+func TestProcessSelectors(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(newTestHandler(t))
+	defer server.Close()
+
+	cli := NewClient(Config{Addr: server.URL, Timeout: 10 * time.Second})
+	first := chaosdtest.StartSleepProcess(t)
+	second := chaosdtest.StartSleepProcess(t)
+
+	attack := &core.ProcessCommand{
+		Action:  core.ProcessStopAction,
+		Cmdline: "^sleep 60$",
+		PPID:    os.Getpid(),
+		Newest:  true,
+		Signal:  int(syscall.SIGSTOP),
+	}
+
+	processes, err := cli.MatchProcesses(context.Background(), attack)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(processes).To(HaveLen(1))
+	g.Expect(processes[0].Pid).To(Equal(second))
+	g.Expect(processes[0].Cmdline).To(Equal("sleep 60"))
+	// the processes are not attacked by the dry run
+	g.Expect(chaosdtest.ProcessStatus(second)).NotTo(Equal("T"))
+
+	resp, err := cli.CreateProcessAttack(context.Background(), attack)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Eventually(func() string { return chaosdtest.ProcessStatus(second) }).Should(Equal("T"))
+	g.Expect(chaosdtest.ProcessStatus(first)).NotTo(Equal("T"))
+
+	_, err = cli.RecoverAttack(context.Background(), resp.UID)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Eventually(func() string { return chaosdtest.ProcessStatus(second) }).ShouldNot(Equal("T"))
+}
+
+func TestAPIError(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(newTestHandler(t))
+	defer server.Close()
+
+	cli := NewClient(Config{Addr: server.URL})
+
+	_, err := cli.CreateProcessAttack(context.Background(), &core.ProcessCommand{
+		Action:  core.ProcessKillAction,
+		Process: "chaosd-process-not-exist",
+		Signal:  int(syscall.SIGKILL),
+	})
+	apiErr, ok := AsAPIError(err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(apiErr.StatusCode).To(Equal(http.StatusInternalServerError))
+	g.Expect(apiErr.Code).To(Equal("error.api.internal_server_error"))
+	g.Expect(apiErr.Message).To(ContainSubstring("process chaosd-process-not-exist not found"))
+
+	_, err = cli.CreateProcessAttack(context.Background(), &core.ProcessCommand{
+		Action:  core.ProcessKillAction,
+		Process: "chaosd-process-not-exist",
+		Signal:  100,
+	})
+	apiErr, ok = AsAPIError(err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(apiErr.StatusCode).To(Equal(http.StatusBadRequest))
+	g.Expect(apiErr.Message).To(ContainSubstring("signal 100 is not valid"))
+
+	_, err = cli.RecoverAttack(context.Background(), "uid-not-exist")
+	_, ok = AsAPIError(err)
+	g.Expect(ok).To(BeTrue())
+}
+
+func TestExperiments(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(newTestHandler(t))
+	defer server.Close()
+
+	cli := NewClient(Config{Addr: server.URL, Timeout: 10 * time.Second})
+	pid := chaosdtest.StartSleepProcess(t)
+
+	resp, err := cli.CreateProcessAttack(context.Background(), &core.ProcessCommand{
+		Action:  core.ProcessStopAction,
+		Process: strconv.Itoa(pid),
+		Signal:  int(syscall.SIGSTOP),
+	})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	defer cli.RecoverAttack(context.Background(), resp.UID)
+
+	list, err := cli.ListExperiments(context.Background(), &core.SearchCommand{
+		Kind:   core.ProcessAttack,
+		Status: core.Success,
+		Limit:  1,
+	})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(list.Experiments).To(HaveLen(1))
+	g.Expect(list.Experiments[0].Uid).To(Equal(resp.UID))
+	g.Expect(list.Total).To(BeNumerically(">=", 1))
+	g.Expect(list.Limit).To(Equal(uint32(1)))
+
+	detail, err := cli.GetExperiment(context.Background(), resp.UID)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(detail.Uid).To(Equal(resp.UID))
+	g.Expect(detail.Kind).To(Equal(core.ProcessAttack))
+	g.Expect(detail.TCRules).To(BeEmpty())
+
+	_, err = cli.GetExperiment(context.Background(), "uid-not-exist")
+	apiErr, ok := AsAPIError(err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(apiErr.StatusCode).To(Equal(http.StatusNotFound))
+
+	_, err = cli.ListExperiments(context.Background(), &core.SearchCommand{Kind: "kind-not-exist"})
+	apiErr, ok = AsAPIError(err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(apiErr.StatusCode).To(Equal(http.StatusBadRequest))
+}
+
+func TestApply(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(newTestHandler(t))
+	defer server.Close()
+
+	cli := NewClient(Config{Addr: server.URL, Timeout: 10 * time.Second})
+	pid := chaosdtest.StartSleepProcess(t)
+	name := fmt.Sprintf("stop-sleep-%d", pid)
+
+	spec, err := core.ParseExperimentSpec([]byte(fmt.Sprintf(
+		"name: %s\nkind: process\nspec:\n  action: stop\n  process: %d\n  signal: 19\n", name, pid)))
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	result, err := cli.ApplyExperiment(context.Background(), spec)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(result.Operation).To(Equal(core.ApplyCreated))
+	g.Eventually(func() string { return chaosdtest.ProcessStatus(pid) }).Should(Equal("T"))
+
+	// the experiment is not created again by the same spec
+	unchanged, err := cli.ApplyExperiment(context.Background(), spec)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(unchanged.Operation).To(Equal(core.ApplyUnchanged))
+	g.Expect(unchanged.Uid).To(Equal(result.Uid))
+
+	// the old experiment is replaced if the spec is changed
+	spec.Spec = []byte(fmt.Sprintf(`{"action": "stop", "process": "%d", "signal": 19, "duration": "1h"}`, pid))
+	configured, err := cli.ApplyExperiment(context.Background(), spec)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(configured.Operation).To(Equal(core.ApplyConfigured))
+	g.Expect(configured.Uid).NotTo(Equal(result.Uid))
+
+	old, err := cli.GetExperiment(context.Background(), result.Uid)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(old.Status).To(Equal(core.Destroyed))
+
+	detail, err := cli.GetExperiment(context.Background(), configured.Uid)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(detail.Name).To(Equal(name))
+	g.Expect(detail.Spec).To(Equal(spec.String()))
+
+	deleted, err := cli.DeleteExperiment(context.Background(), name)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(deleted.Uid).To(Equal(configured.Uid))
+	g.Eventually(func() string { return chaosdtest.ProcessStatus(pid) }).ShouldNot(Equal("T"))
+
+	_, err = cli.DeleteExperiment(context.Background(), name)
+	apiErr, ok := AsAPIError(err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(apiErr.StatusCode).To(Equal(http.StatusNotFound))
+}
+
+func TestWorkflow(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(newTestHandler(t))
+	defer server.Close()
+
+	cli := NewClient(Config{Addr: server.URL, Timeout: 10 * time.Second})
+	first, second, third := chaosdtest.StartSleepProcess(t), chaosdtest.StartSleepProcess(t), chaosdtest.StartSleepProcess(t)
+
+	spec, err := core.ParseWorkflowSpec([]byte(fmt.Sprintf(`
+name: stop-sleep
+steps:
+- name: stop-first
+  kind: process
+  spec: {action: stop, process: %d, signal: 19}
+- name: wait
+  suspend: 100ms
+- name: stop-others
+  parallel:
+  - name: stop-second
+    kind: process
+    spec: {action: stop, process: %d, signal: 19}
+    duration: 100ms
+  - name: stop-third
+    kind: process
+    spec: {action: stop, process: %d, signal: 19}
+`, first, second, third)))
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	status, err := cli.RunWorkflow(context.Background(), spec)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(status.Nodes).To(HaveLen(5))
+
+	g.Eventually(func() string {
+		status, _ = cli.GetWorkflow(context.Background(), status.Uid)
+		return status.Status
+	}, 5*time.Second).Should(Equal(core.WorkflowSucceeded))
+	for _, node := range status.Nodes {
+		g.Expect(node.Status).To(Equal(core.WorkflowSucceeded), node.Path)
+	}
+	// the experiments are recovered when the workflow ends
+	for _, pid := range []int{first, second, third} {
+		g.Expect(chaosdtest.ProcessStatus(pid)).NotTo(Equal("T"))
+	}
+
+	// the workflow is aborted while suspending
+	spec.Steps = spec.Steps[:2]
+	spec.Steps[1].Suspend = "1h"
+	status, err = cli.RunWorkflow(context.Background(), spec)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Eventually(func() string {
+		current, err := cli.GetWorkflow(context.Background(), status.Uid)
+		if err != nil {
+			return ""
+		}
+		return current.Nodes[1].Status
+	}).Should(Equal(core.WorkflowRunning))
+	g.Expect(chaosdtest.ProcessStatus(first)).To(Equal("T"))
+
+	status, err = cli.AbortWorkflow(context.Background(), status.Uid)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(status.Status).To(Equal(core.WorkflowAborted))
+	g.Expect(status.Nodes[1].Status).To(Equal(core.WorkflowAborted))
+	g.Expect(chaosdtest.ProcessStatus(first)).NotTo(Equal("T"))
+
+	_, err = cli.AbortWorkflow(context.Background(), status.Uid)
+	apiErr, ok := AsAPIError(err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(apiErr.StatusCode).To(Equal(http.StatusConflict))
+
+	spec.Steps[1].Suspend = ""
+	_, err = cli.RunWorkflow(context.Background(), spec)
+	apiErr, ok = AsAPIError(err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(apiErr.StatusCode).To(Equal(http.StatusBadRequest))
+}
+
+func TestSchedule(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(newTestHandler(t))
+	defer server.Close()
+
+	cli := NewClient(Config{Addr: server.URL, Timeout: 10 * time.Second})
+	pid := chaosdtest.StartSleepProcess(t)
+
+	spec, err := core.ParseScheduleSpec([]byte(fmt.Sprintf(`
+name: stop-sleep
+cron: "@every 1s"
+duration: 1h
+concurrencyPolicy: replace
+historyLimit: 1
+kind: process
+spec: {action: stop, process: %d, signal: 19}
+`, pid)))
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	status, err := cli.CreateSchedule(context.Background(), spec)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(status.NextScheduleTime).NotTo(BeNil())
+
+	// the running experiment is replaced by the next run, and only one finished experiment is kept
+	g.Eventually(func() []*core.Experiment {
+		status, _ = cli.GetSchedule(context.Background(), status.Uid)
+		return status.Experiments
+	}, 5*time.Second).Should(HaveLen(2))
+	g.Expect(chaosdtest.ProcessStatus(pid)).To(Equal("T"))
+	g.Consistently(func() int {
+		status, _ = cli.GetSchedule(context.Background(), status.Uid)
+		return len(status.Experiments)
+	}, 1500*time.Millisecond).Should(Equal(2))
+
+	status, err = cli.PauseSchedule(context.Background(), status.Uid)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(status.Paused).To(BeTrue())
+	g.Expect(status.NextScheduleTime).To(BeNil())
+
+	status, err = cli.ResumeSchedule(context.Background(), status.Uid)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(status.Paused).To(BeFalse())
+
+	// the running experiment is recovered when the schedule is deleted
+	_, err = cli.DeleteSchedule(context.Background(), status.Uid)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(chaosdtest.ProcessStatus(pid)).NotTo(Equal("T"))
+
+	_, err = cli.GetSchedule(context.Background(), status.Uid)
+	apiErr, ok := AsAPIError(err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(apiErr.StatusCode).To(Equal(http.StatusNotFound))
+
+	spec.Cron = "every second"
+	_, err = cli.CreateSchedule(context.Background(), spec)
+	apiErr, ok = AsAPIError(err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(apiErr.StatusCode).To(Equal(http.StatusBadRequest))
+}
+
+func TestRetry(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	handler := newTestHandler(t)
+	var requests, failures int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	cli := NewClient(Config{Addr: server.URL, MaxRetries: 3, RetryBackoff: time.Millisecond})
+	pid := chaosdtest.StartSleepProcess(t)
+
+	// the attack is not retried
+	atomic.StoreInt32(&failures, 1)
+	_, err := cli.CreateProcessAttack(context.Background(), &core.ProcessCommand{
+		Action:  core.ProcessStopAction,
+		Process: strconv.Itoa(pid),
+		Signal:  int(syscall.SIGSTOP),
+	})
+	apiErr, ok := AsAPIError(err)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(apiErr.StatusCode).To(Equal(http.StatusServiceUnavailable))
+	g.Expect(atomic.LoadInt32(&requests)).To(Equal(int32(1)))
+
+	resp, err := cli.CreateProcessAttack(context.Background(), &core.ProcessCommand{
+		Action:  core.ProcessStopAction,
+		Process: strconv.Itoa(pid),
+		Signal:  int(syscall.SIGSTOP),
+	})
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// the recovery is retried until the server is available
+	atomic.StoreInt32(&requests, 0)
+	atomic.StoreInt32(&failures, 2)
+	_, err = cli.RecoverAttack(context.Background(), resp.UID)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(atomic.LoadInt32(&requests)).To(Equal(int32(3)))
+
+	// the request fails if the server is still unavailable after retries
+	atomic.StoreInt32(&requests, 0)
+	atomic.StoreInt32(&failures, 10)
+	_, err = cli.RecoverAttack(context.Background(), resp.UID)
+	g.Expect(err).Should(HaveOccurred())
+	g.Expect(atomic.LoadInt32(&requests)).To(Equal(int32(4)))
+}
+
+func TestTimeout(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	cli := NewClient(Config{Addr: server.URL, Timeout: 50 * time.Millisecond})
+	_, err := cli.RecoverAttack(context.Background(), "uid")
+	g.Expect(err).Should(HaveOccurred())
+	_, ok := AsAPIError(err)
+	g.Expect(ok).To(BeFalse())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cli = NewClient(Config{Addr: server.URL, MaxRetries: 3})
+	_, err = cli.RecoverAttack(ctx, "uid")
+	g.Expect(err).Should(HaveOccurred())
+}
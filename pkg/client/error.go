@@ -0,0 +1,57 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pingcap/errors"
+)
+
+// APIError is the error responded by chaosd server
+type APIError struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int
+	// Message is the message of the error
+	Message string
+	// Code is the type of the error, such as error.api.internal_server_error
+	Code string
+	// FullText is the error message with the stack
+	FullText string
+}
+
+func (e *APIError) Error() string {
+	if len(e.Message) == 0 {
+		return fmt.Sprintf("chaosd server responded %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+
+	return fmt.Sprintf("chaosd server responded %d: %s", e.StatusCode, e.Message)
+}
+
+// AsAPIError returns the APIError if the cause of err is an APIError
+func AsAPIError(err error) (*APIError, bool) {
+	apiErr, ok := errors.Cause(err).(*APIError)
+	return apiErr, ok
+}
+
+// temporary returns true if the server is temporarily unable to handle the request
+func (e *APIError) temporary() bool {
+	switch e.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
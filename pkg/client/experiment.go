@@ -0,0 +1,78 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+const experimentsPath = "api/experiments"
+
+// ListExperiments lists the experiments matching the conditions, all the experiments are listed
+// if neither kind nor status is specified. The UID and All of the conditions are ignored.
+func (c *Client) ListExperiments(ctx context.Context, conds *core.SearchCommand) (*core.ExperimentList, error) {
+	query := url.Values{}
+	if len(conds.Kind) > 0 {
+		query.Set("kind", conds.Kind)
+	}
+	if len(conds.Status) > 0 {
+		query.Set("status", conds.Status)
+	}
+	if conds.Limit > 0 {
+		query.Set("limit", strconv.FormatUint(uint64(conds.Limit), 10))
+	}
+	if conds.Offset > 0 {
+		query.Set("offset", strconv.FormatUint(uint64(conds.Offset), 10))
+	}
+	if conds.Asc {
+		query.Set("asc", "true")
+	}
+
+	path := experimentsPath
+	if len(query) > 0 {
+		path = fmt.Sprintf("%s?%s", path, query.Encode())
+	}
+
+	list := &core.ExperimentList{}
+	if err := c.doJSON(ctx, request{
+		method:     http.MethodGet,
+		path:       path,
+		idempotent: true,
+	}, list); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// GetExperiment returns the experiment of the uid with the rules applied by it,
+// an *APIError with status 404 is returned if the experiment is not found.
+func (c *Client) GetExperiment(ctx context.Context, uid string) (*core.ExperimentDetail, error) {
+	detail := &core.ExperimentDetail{}
+	if err := c.doJSON(ctx, request{
+		method:     http.MethodGet,
+		path:       fmt.Sprintf("%s/%s", experimentsPath, url.PathEscape(uid)),
+		idempotent: true,
+	}, detail); err != nil {
+		return nil, err
+	}
+
+	return detail, nil
+}
@@ -15,11 +15,18 @@ package client
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/pingcap/errors"
+
+	"github.com/chaos-mesh/chaosd/pkg/server/utils"
 )
 
 // BodyOption sets the type and content of the body
@@ -37,50 +44,128 @@ func withJsonBody(body []byte) BodyOption {
 	}
 }
 
-func doRequest(
-	cli *http.Client,
+// request describes a request to chaosd server
+type request struct {
+	method string
+	path   string
+	// idempotent request is retried if the server is unreachable or unavailable
+	idempotent bool
+	opts       []BodyOption
+}
+
+// doJSON sends the request, and decodes the response body into out
+func (c *Client) doJSON(ctx context.Context, req request, out interface{}) error {
+	data, err := c.do(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return errors.WithStack(json.Unmarshal(data, out))
+}
+
+// do sends the request and returns the response body, the request is retried with backoff if it is idempotent.
+// The error responded by chaosd server is returned as *APIError.
+func (c *Client) do(ctx context.Context, req request) ([]byte, error) {
+	url := fmt.Sprintf("%s/%s", strings.TrimSuffix(c.cfg.Addr, "/"), req.path)
+
+	retries := 0
+	if req.idempotent {
+		retries = c.cfg.MaxRetries
+	}
+
+	backoff := c.cfg.RetryBackoff
+	for i := 0; ; i++ {
+		data, err := c.doRequest(ctx, url, req.method, req.opts...)
+		if err == nil || i >= retries || !retryable(ctx, err) {
+			return data, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.WithStack(ctx.Err())
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+// retryable returns true if the request failed because the server is unreachable or unavailable
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if apiErr, ok := AsAPIError(err); ok {
+		return apiErr.temporary()
+	}
+
+	return true
+}
+
+func (c *Client) doRequest(
+	ctx context.Context,
 	url, method string,
 	opts ...BodyOption,
-) ([]byte, []byte, error) {
+) ([]byte, error) {
 	b := &bodyOption{}
 	for _, o := range opts {
 		o(b)
 	}
-	req, err := http.NewRequest(method, url, b.body)
+
+	if c.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.cfg.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, b.body)
 	if err != nil {
-		return nil, nil, errors.WithStack(err)
+		return nil, errors.WithStack(err)
 	}
 
 	if b.contentType != "" {
 		req.Header.Set("Content-Type", b.contentType)
 	}
 
-	resp, apiErr, err := dial(cli, req)
-	if err != nil {
-		return nil, nil, errors.WithStack(err)
-	}
-
-	return resp, apiErr, nil
+	return dial(c.client, req)
 }
 
-func dial(cli *http.Client, req *http.Request) ([]byte, []byte, error) {
+func dial(cli *http.Client, req *http.Request) ([]byte, error) {
 	resp, err := cli.Do(req)
 	if err != nil {
-		return nil, nil, err
+		return nil, errors.WithStack(err)
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		var apiErr []byte
-		apiErr, err = ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return nil, nil, err
-		}
-		return nil, apiErr, nil
-	}
 
 	content, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, nil, err
+		return nil, errors.WithStack(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeAPIError(resp.StatusCode, content)
 	}
-	return content, nil, nil
+
+	return content, nil
+}
+
+// decodeAPIError decodes the error responded by chaosd server,
+// the body is used as the message if it is not an utils.APIError, such as the error responded by a proxy.
+func decodeAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: statusCode}
+
+	e := &utils.APIError{}
+	if err := json.Unmarshal(body, e); err != nil || !e.Error {
+		apiErr.Message = strings.TrimSpace(string(body))
+		return apiErr
+	}
+
+	apiErr.Message = e.Message
+	apiErr.Code = e.Code
+	apiErr.FullText = e.FullText
+
+	return apiErr
 }
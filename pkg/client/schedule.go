@@ -0,0 +1,101 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/server/utils"
+)
+
+const schedulePath = "api/schedule"
+
+// CreateSchedule creates the schedule of the spec on chaosd server.
+func (c *Client) CreateSchedule(ctx context.Context, spec *core.ScheduleSpec) (*core.ScheduleStatus, error) {
+	status := &core.ScheduleStatus{}
+	if err := c.doJSON(ctx, request{
+		method: http.MethodPost,
+		path:   schedulePath,
+		opts:   []BodyOption{withJsonBody([]byte(spec.String()))},
+	}, status); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// ListSchedules lists all the schedules, the experiments created by them are not included.
+func (c *Client) ListSchedules(ctx context.Context) ([]*core.ScheduleStatus, error) {
+	schedules := make([]*core.ScheduleStatus, 0)
+	if err := c.doJSON(ctx, request{
+		method:     http.MethodGet,
+		path:       schedulePath,
+		idempotent: true,
+	}, &schedules); err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+// GetSchedule returns the schedule of the uid and the experiments created by it
+func (c *Client) GetSchedule(ctx context.Context, uid string) (*core.ScheduleStatus, error) {
+	return c.schedule(ctx, http.MethodGet, uid, "")
+}
+
+// PauseSchedule stops creating experiments by the schedule
+func (c *Client) PauseSchedule(ctx context.Context, uid string) (*core.ScheduleStatus, error) {
+	return c.schedule(ctx, http.MethodPut, uid, "pause")
+}
+
+// ResumeSchedule continues creating experiments by the paused schedule
+func (c *Client) ResumeSchedule(ctx context.Context, uid string) (*core.ScheduleStatus, error) {
+	return c.schedule(ctx, http.MethodPut, uid, "resume")
+}
+
+// DeleteSchedule deletes the schedule and recovers its running experiments
+func (c *Client) DeleteSchedule(ctx context.Context, uid string) (*utils.Response, error) {
+	resp := &utils.Response{}
+	if err := c.doJSON(ctx, request{
+		method:     http.MethodDelete,
+		path:       fmt.Sprintf("%s/%s", schedulePath, url.PathEscape(uid)),
+		idempotent: true,
+	}, resp); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (c *Client) schedule(ctx context.Context, method, uid, operation string) (*core.ScheduleStatus, error) {
+	path := fmt.Sprintf("%s/%s", schedulePath, url.PathEscape(uid))
+	if len(operation) > 0 {
+		path = fmt.Sprintf("%s/%s", path, operation)
+	}
+
+	status := &core.ScheduleStatus{}
+	if err := c.doJSON(ctx, request{
+		method:     method,
+		path:       path,
+		idempotent: true,
+	}, status); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+const workflowPath = "api/workflow"
+
+// RunWorkflow starts the workflow of the spec on chaosd server, the uid of the workflow is in the status.
+func (c *Client) RunWorkflow(ctx context.Context, spec *core.WorkflowSpec) (*core.WorkflowStatus, error) {
+	status := &core.WorkflowStatus{}
+	if err := c.doJSON(ctx, request{
+		method: http.MethodPost,
+		path:   workflowPath,
+		opts:   []BodyOption{withJsonBody([]byte(spec.String()))},
+	}, status); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// ListWorkflows lists all the workflows, the latest one is the first.
+func (c *Client) ListWorkflows(ctx context.Context) ([]*core.Workflow, error) {
+	workflows := make([]*core.Workflow, 0)
+	if err := c.doJSON(ctx, request{
+		method:     http.MethodGet,
+		path:       workflowPath,
+		idempotent: true,
+	}, &workflows); err != nil {
+		return nil, err
+	}
+
+	return workflows, nil
+}
+
+// GetWorkflow returns the workflow of the uid and the states of its steps
+func (c *Client) GetWorkflow(ctx context.Context, uid string) (*core.WorkflowStatus, error) {
+	return c.workflow(ctx, http.MethodGet, uid)
+}
+
+// AbortWorkflow aborts the running workflow, the experiments created by it are recovered in reverse order.
+func (c *Client) AbortWorkflow(ctx context.Context, uid string) (*core.WorkflowStatus, error) {
+	return c.workflow(ctx, http.MethodDelete, uid)
+}
+
+func (c *Client) workflow(ctx context.Context, method, uid string) (*core.WorkflowStatus, error) {
+	status := &core.WorkflowStatus{}
+	if err := c.doJSON(ctx, request{
+		method:     method,
+		path:       fmt.Sprintf("%s/%s", workflowPath, url.PathEscape(uid)),
+		idempotent: true,
+	}, status); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
@@ -18,8 +18,13 @@ import (
 
 	"github.com/pingcap/errors"
 	flag "github.com/spf13/pflag"
+
+	"github.com/chaos-mesh/chaosd/pkg/utils"
 )
 
+// DefaultMemoryReserve is the memory kept available for the host when stressing memory
+const DefaultMemoryReserve = "5%"
+
 // Config defines the configuration for Chaosd.
 type Config struct {
 	flagSet *flag.FlagSet
@@ -32,6 +37,13 @@ type Config struct {
 	EnablePprof bool
 	PprofPort   int
 	Platform    string
+
+	// NetworkProfileDir is the directory of the YAML files which define network profiles
+	NetworkProfileDir string
+
+	// MemoryReserve is the memory which the memory stressors must leave available for the host,
+	// such as 1g or 5% of the total memory. Empty means no reserve.
+	MemoryReserve string
 }
 
 // Parse parses flag definitions from the argument list.
@@ -58,6 +70,13 @@ func (c *Config) Validate() error {
 		return errors.Errorf("container runtime %s is not supported", c.Runtime)
 	}
 
+	if len(c.MemoryReserve) > 0 {
+		if _, err := utils.ParseSize(c.MemoryReserve, 0); err != nil {
+			return errors.Errorf("memory reserve %s not valid, it should be bytes with the unit b, k, m, g or a percent",
+				c.MemoryReserve)
+		}
+	}
+
 	return nil
 }
 
@@ -81,7 +100,7 @@ func checkPlatform(platform string) bool {
 	return false
 }
 
-var supportRuntimes = []string{"docker", "runtime"}
+var supportRuntimes = []string{"docker", "containerd"}
 
 func checkRuntime(runtime string) bool {
 	for _, r := range supportRuntimes {
@@ -17,6 +17,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"syscall"
 
 	"github.com/containerd/containerd"
@@ -40,6 +41,20 @@ const (
 	containerdDefaultNS      = "k8s.io"
 )
 
+// Runtime returns the container runtime which the container ID belongs to,
+// the container ID should be in the format of docker://<id> or containerd://<id>.
+func Runtime(containerID string) (string, error) {
+	switch {
+	case strings.HasPrefix(containerID, dockerProtocolPrefix):
+		return containerRuntimeDocker, nil
+	case strings.HasPrefix(containerID, containerdProtocolPrefix):
+		return containerRuntimeContainerd, nil
+	}
+
+	return "", fmt.Errorf("container id %s should start with %s or %s",
+		containerID, dockerProtocolPrefix, containerdProtocolPrefix)
+}
+
 // CRIClient represents a struct which can give you information about container runtime
 type CRIClient interface {
 	GetPidFromContainerID(ctx context.Context, containerID string) (uint32, error)
@@ -0,0 +1,28 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"github.com/chaos-mesh/chaosd/pkg/container"
+)
+
+// validContainer checks the container ID which the attack is applied in, empty means the host.
+func validContainer(containerID string) error {
+	if len(containerID) == 0 {
+		return nil
+	}
+
+	_, err := container.Runtime(containerID)
+	return err
+}
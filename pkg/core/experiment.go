@@ -36,10 +36,17 @@ const (
 type ExperimentStore interface {
 	List(ctx context.Context) ([]*Experiment, error)
 	ListByConditions(ctx context.Context, conds *SearchCommand) ([]*Experiment, error)
+	CountByConditions(ctx context.Context, conds *SearchCommand) (int64, error)
 	ListByStatus(ctx context.Context, status string) ([]*Experiment, error)
 	FindByUid(ctx context.Context, uid string) (*Experiment, error)
+	FindByName(ctx context.Context, name string) (*Experiment, error)
+	ListBySchedule(ctx context.Context, schedule string) ([]*Experiment, error)
 	Set(ctx context.Context, exp *Experiment) error
 	Update(ctx context.Context, uid, status, msg string, command string) error
+	// UpdateCommand updates the recover command only if the experiment is still in the status
+	UpdateCommand(ctx context.Context, uid, status, command string) error
+	UpdateSchedule(ctx context.Context, uid, schedule string) error
+	Delete(ctx context.Context, uid string) error
 }
 
 // Experiment represents an experiment instance.
@@ -54,4 +61,39 @@ type Experiment struct {
 	Kind           string `json:"kind"`
 	Action         string `json:"action"`
 	RecoverCommand string `json:"recover_command"`
+	// Recoverable is false if the experiment can not be recovered, such as the killed processes
+	Recoverable bool `json:"recoverable"`
+	// Name and Spec are set if the experiment is created by applying an experiment spec,
+	// the Spec is the original spec in JSON.
+	Name string `gorm:"index:experiment_name" json:"name,omitempty"`
+	Spec string `json:"spec,omitempty"`
+	// Schedule is the uid of the schedule which creates the experiment
+	Schedule string `gorm:"index:experiment_schedule" json:"schedule,omitempty"`
+}
+
+// ExperimentOrigin is the name and the spec set on the experiment when it is created,
+// both of them are empty if the experiment is not created by applying an experiment spec.
+type ExperimentOrigin struct {
+	Name string
+	Spec string
+}
+
+// ExperimentList represents a page of the experiments matching the search conditions.
+type ExperimentList struct {
+	Experiments []*Experiment `json:"experiments"`
+	// Total is the count of all the experiments matching the conditions
+	Total  int64  `json:"total"`
+	Offset uint32 `json:"offset"`
+	Limit  uint32 `json:"limit"`
+}
+
+// ExperimentDetail represents an experiment and the rules applied by it.
+type ExperimentDetail struct {
+	*Experiment
+	IPSetRules    []*IPSetRule    `json:"ipset_rules"`
+	IptablesRules []*IptablesRule `json:"iptables_rules"`
+	TCRules       []*TCRule       `json:"tc_rules"`
+	IFBRules      []*IFBRule      `json:"ifb_rules"`
+	// ProcessEvents are the signals sent by the process attack
+	ProcessEvents []*ProcessEvent `json:"process_events,omitempty"`
 }
@@ -39,6 +39,43 @@ type NetworkCommand struct {
 	IPAddress   string
 	IPProtocol  string
 	Hostname    string
+	Duration    string
+	// IPFamily is the IP family of the traffic to impact, ipv4 or ipv6, empty means both of them
+	IPFamily string
+	// Container is the container whose network namespace the attack is applied in,
+	// such as docker://<id> or containerd://<id>, empty means the host.
+	Container string
+
+	// used in partition action, and the actions of traffic control
+	Direction  string
+	Port       string
+	AcceptList bool
+	Reject     bool
+
+	// used in bandwidth action
+	Rate     string
+	Limit    uint32
+	Buffer   uint32
+	Peakrate uint64
+	Minburst uint32
+
+	// used in reorder and netem action
+	Reorder            string
+	ReorderCorrelation string
+	Gap                int
+
+	// used in netem action, the delay correlation is set by Correlation
+	Loss                 string
+	LossCorrelation      string
+	Duplicate            string
+	DuplicateCorrelation string
+	Corrupt              string
+	CorruptCorrelation   string
+
+	// used in profile action, the profile is resolved by the name and recorded by chaosd,
+	// so that the experiment can be recovered even if the profile is changed.
+	Profile        string
+	NetworkProfile *NetworkProfile `json:",omitempty"`
 }
 
 const (
@@ -46,14 +83,79 @@ const (
 	NetworkLossAction      = "loss"
 	NetworkCorruptAction   = "corrupt"
 	NetworkDuplicateAction = "duplicate"
+	NetworkPartitionAction = "partition"
+	NetworkBandwidthAction = "bandwidth"
+	NetworkReorderAction   = "reorder"
+	// NetworkNetemAction combines delay, loss, duplicate, corrupt and reorder in one experiment
+	NetworkNetemAction = "netem"
+	// NetworkProfileAction applies a named preset of network condition
+	NetworkProfileAction = "profile"
 )
 
+const (
+	DirectionTo   = "to"
+	DirectionFrom = "from"
+	DirectionBoth = "both"
+
+	// DirectionEgress and DirectionIngress are the directions of traffic control,
+	// the empty direction is the same as egress.
+	DirectionEgress  = "egress"
+	DirectionIngress = "ingress"
+)
+
+const (
+	IPFamilyIPv4 = "ipv4"
+	IPFamilyIPv6 = "ipv6"
+)
+
+// EnterNS returns true if the attack is applied in the network namespace of a container
+func (n *NetworkCommand) EnterNS() bool {
+	return len(n.Container) > 0
+}
+
 func (n *NetworkCommand) Validate() error {
+	if !utils.CheckDuration(n.Duration) {
+		return errors.Errorf("duration %s not valid", n.Duration)
+	}
+
+	if err := validContainer(n.Container); err != nil {
+		return errors.WithStack(err)
+	}
+
+	switch n.IPFamily {
+	case "", IPFamilyIPv4, IPFamilyIPv6:
+	default:
+		return errors.Errorf("ip family %s not supported", n.IPFamily)
+	}
+
+	if n.NeedApplyTC() {
+		switch n.Direction {
+		case "", DirectionEgress, DirectionIngress, DirectionBoth:
+		default:
+			return errors.Errorf("direction %s not supported", n.Direction)
+		}
+
+		// the traffic control without ipset filter is applied on all the egress traffic
+		if len(n.IPFamily) > 0 && n.NeedApplyEgressTC() && !n.NeedApplyIPSet() {
+			return errors.New("ip family can only be used in conjunction with ip or hostname in egress direction")
+		}
+	}
+
 	switch n.Action {
 	case NetworkDelayAction:
 		return n.validNetworkDelay()
 	case NetworkLossAction, NetworkCorruptAction, NetworkDuplicateAction:
 		return n.validNetworkCommon()
+	case NetworkPartitionAction:
+		return n.validNetworkPartition()
+	case NetworkBandwidthAction:
+		return n.validNetworkBandwidth()
+	case NetworkReorderAction:
+		return n.validNetworkReorder()
+	case NetworkNetemAction:
+		return n.validNetworkNetem()
+	case NetworkProfileAction:
+		return n.validNetworkProfile()
 	default:
 		return errors.Errorf("network action %s not supported", n.Action)
 	}
@@ -113,6 +215,199 @@ func (n *NetworkCommand) validNetworkCommon() error {
 	return checkProtocolAndPorts(n.IPProtocol, n.SourcePort, n.EgressPort)
 }
 
+func (n *NetworkCommand) validNetworkPartition() error {
+	switch n.Direction {
+	case DirectionTo, DirectionFrom, DirectionBoth:
+	default:
+		return errors.Errorf("direction %s not supported", n.Direction)
+	}
+
+	if !utils.CheckIPs(n.IPAddress) {
+		return errors.Errorf("ip addressed %s not valid", n.IPAddress)
+	}
+
+	if !utils.CheckPorts(n.Port) {
+		return errors.Errorf("ports %s not valid", n.Port)
+	}
+
+	if n.AcceptList && !n.NeedApplyIPSet() {
+		return errors.New("ip or hostname is required in accept list mode")
+	}
+
+	if !n.NeedApplyIPSet() && len(n.Port) == 0 && len(n.IPProtocol) == 0 {
+		// blocking all the traffic also blocks the loopback and the connections used to recover the attack
+		return errors.New("one of ip, hostname, port and protocol is required, all the traffic of the host can not be blocked")
+	}
+
+	return checkProtocolAndPorts(n.IPProtocol, "", n.Port)
+}
+
+func (n *NetworkCommand) validNetworkBandwidth() error {
+	if len(n.Rate) == 0 {
+		return errors.New("rate is required")
+	}
+
+	if rate, err := convertUnitToBytes(n.Rate); err != nil || rate == 0 {
+		return errors.Errorf("rate %s not valid", n.Rate)
+	}
+
+	if n.Limit == 0 {
+		return errors.New("limit is required")
+	}
+
+	if n.Buffer == 0 {
+		return errors.New("buffer is required")
+	}
+
+	if (n.Peakrate > 0) != (n.Minburst > 0) {
+		return errors.New("peakrate and minburst should be set together")
+	}
+
+	if len(n.Device) == 0 {
+		return errors.New("device is required")
+	}
+
+	if !utils.CheckIPs(n.IPAddress) {
+		return errors.Errorf("ip addressed %s not valid", n.IPAddress)
+	}
+
+	return checkProtocolAndPorts(n.IPProtocol, n.SourcePort, n.EgressPort)
+}
+
+func (n *NetworkCommand) validNetworkReorder() error {
+	if len(n.Latency) == 0 {
+		return errors.New("latency is required, the packets are reordered by delaying the others")
+	}
+
+	if _, err := time.ParseDuration(n.Latency); err != nil {
+		return errors.WithMessage(err, fmt.Sprintf("latency %s not valid", n.Latency))
+	}
+
+	if len(n.Reorder) == 0 {
+		return errors.New("reorder is required")
+	}
+
+	if !utils.CheckPercent(n.Reorder) {
+		return errors.Errorf("reorder %s not valid", n.Reorder)
+	}
+
+	if !utils.CheckPercent(n.ReorderCorrelation) {
+		return errors.Errorf("reorder correlation %s not valid", n.ReorderCorrelation)
+	}
+
+	if n.Gap < 0 {
+		return errors.Errorf("gap %d not valid", n.Gap)
+	}
+
+	if len(n.Device) == 0 {
+		return errors.New("device is required")
+	}
+
+	if !utils.CheckIPs(n.IPAddress) {
+		return errors.Errorf("ip addressed %s not valid", n.IPAddress)
+	}
+
+	return checkProtocolAndPorts(n.IPProtocol, n.SourcePort, n.EgressPort)
+}
+
+func (n *NetworkCommand) validNetworkNetem() error {
+	if len(n.Latency) == 0 && len(n.Loss) == 0 && len(n.Duplicate) == 0 &&
+		len(n.Corrupt) == 0 && len(n.Reorder) == 0 {
+		return errors.New("one of latency, loss, duplicate, corrupt and reorder is required")
+	}
+
+	if len(n.Latency) > 0 {
+		if _, err := time.ParseDuration(n.Latency); err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("latency %s not valid", n.Latency))
+		}
+	}
+
+	if len(n.Jitter) > 0 {
+		if len(n.Latency) == 0 {
+			return errors.New("jitter can only be used in conjunction with latency")
+		}
+
+		if _, err := time.ParseDuration(n.Jitter); err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("jitter %s not valid", n.Jitter))
+		}
+	}
+
+	if len(n.Reorder) > 0 && len(n.Latency) == 0 {
+		return errors.New("reorder can only be used in conjunction with latency")
+	}
+
+	if n.Gap < 0 {
+		return errors.Errorf("gap %d not valid", n.Gap)
+	}
+
+	for _, p := range []struct {
+		name  string
+		value string
+	}{
+		{"correlation", n.Correlation},
+		{"loss", n.Loss},
+		{"loss correlation", n.LossCorrelation},
+		{"duplicate", n.Duplicate},
+		{"duplicate correlation", n.DuplicateCorrelation},
+		{"corrupt", n.Corrupt},
+		{"corrupt correlation", n.CorruptCorrelation},
+		{"reorder", n.Reorder},
+		{"reorder correlation", n.ReorderCorrelation},
+	} {
+		if !utils.CheckPercent(p.value) {
+			return errors.Errorf("%s %s not valid", p.name, p.value)
+		}
+	}
+
+	if len(n.Device) == 0 {
+		return errors.New("device is required")
+	}
+
+	if !utils.CheckIPs(n.IPAddress) {
+		return errors.Errorf("ip addressed %s not valid", n.IPAddress)
+	}
+
+	return checkProtocolAndPorts(n.IPProtocol, n.SourcePort, n.EgressPort)
+}
+
+func (n *NetworkCommand) validNetworkProfile() error {
+	if len(n.Profile) == 0 {
+		return errors.New("profile name is required")
+	}
+
+	if len(n.Device) == 0 {
+		return errors.New("device is required")
+	}
+
+	if !utils.CheckIPs(n.IPAddress) {
+		return errors.Errorf("ip addressed %s not valid", n.IPAddress)
+	}
+
+	return checkProtocolAndPorts(n.IPProtocol, n.SourcePort, n.EgressPort)
+}
+
+// SetDefault sets the default values of the action
+func (n *NetworkCommand) SetDefault() {
+	switch n.Action {
+	case NetworkPartitionAction:
+		n.SetDefaultForNetworkPartition()
+	case NetworkDelayAction:
+		n.SetDefaultForNetworkDelay()
+	case NetworkLossAction:
+		n.SetDefaultForNetworkLoss()
+	case NetworkReorderAction:
+		n.SetDefaultForNetworkReorder()
+	case NetworkNetemAction:
+		n.SetDefaultForNetworkNetem()
+	}
+}
+
+func (n *NetworkCommand) SetDefaultForNetworkPartition() {
+	if len(n.Direction) == 0 {
+		n.Direction = DirectionBoth
+	}
+}
+
 func (n *NetworkCommand) SetDefaultForNetworkDelay() {
 	if len(n.Jitter) == 0 {
 		n.Jitter = "0ms"
@@ -129,6 +424,23 @@ func (n *NetworkCommand) SetDefaultForNetworkLoss() {
 	}
 }
 
+func (n *NetworkCommand) SetDefaultForNetworkReorder() {
+	if len(n.ReorderCorrelation) == 0 {
+		n.ReorderCorrelation = "0"
+	}
+}
+
+func (n *NetworkCommand) SetDefaultForNetworkNetem() {
+	n.SetDefaultForNetworkDelay()
+	n.SetDefaultForNetworkReorder()
+
+	for _, corr := range []*string{&n.LossCorrelation, &n.DuplicateCorrelation, &n.CorruptCorrelation} {
+		if len(*corr) == 0 {
+			*corr = "0"
+		}
+	}
+}
+
 func checkProtocolAndPorts(p string, sports string, dports string) error {
 	if !utils.CheckPorts(sports) {
 		return errors.Errorf("source ports %s not valid", sports)
@@ -233,46 +545,160 @@ func (n *NetworkCommand) ToDuplicateNetem() (*pb.Netem, error) {
 	}, nil
 }
 
-func (n *NetworkCommand) ToTC(ipset string) (*pb.Tc, error) {
-	tc := &pb.Tc{
-		Type:       pb.Tc_NETEM,
-		Ipset:      ipset,
-		Protocol:   n.IPProtocol,
-		SourcePort: n.SourcePort,
-		EgressPort: n.EgressPort,
+func (n *NetworkCommand) ToBandwidthSpec() *BandwidthSpec {
+	spec := &BandwidthSpec{
+		Rate:   n.Rate,
+		Limit:  n.Limit,
+		Buffer: n.Buffer,
+	}
+
+	if n.Peakrate > 0 && n.Minburst > 0 {
+		peakrate, minburst := n.Peakrate, n.Minburst
+		spec.Peakrate = &peakrate
+		spec.Minburst = &minburst
+	}
+
+	return spec
+}
+
+// ToTcParameter converts the action to the parameters of traffic control, which is recorded in the tc rule.
+func (n *NetworkCommand) ToTcParameter() (*TcParameter, error) {
+	tc := &TcParameter{
+		Device: n.Device,
 	}
 
-	var (
-		netem *pb.Netem
-		err   error
-	)
 	switch n.Action {
 	case NetworkDelayAction:
-		if netem, err = n.ToDelayNetem(); err != nil {
-			return nil, errors.WithStack(err)
+		tc.Delay = &DelaySpec{
+			Latency:     n.Latency,
+			Correlation: n.Correlation,
+			Jitter:      n.Jitter,
 		}
 	case NetworkLossAction:
-		if netem, err = n.ToLossNetem(); err != nil {
-			return nil, errors.WithStack(err)
+		tc.Loss = &LossSpec{
+			Loss:        n.Percent,
+			Correlation: n.Correlation,
 		}
 	case NetworkCorruptAction:
-		if netem, err = n.ToCorruptNetem(); err != nil {
-			return nil, errors.WithStack(err)
+		tc.Corrupt = &CorruptSpec{
+			Corrupt:     n.Percent,
+			Correlation: n.Correlation,
 		}
 	case NetworkDuplicateAction:
-		if netem, err = n.ToDuplicateNetem(); err != nil {
-			return nil, errors.WithStack(err)
+		tc.Duplicate = &DuplicateSpec{
+			Duplicate:   n.Percent,
+			Correlation: n.Correlation,
+		}
+	case NetworkBandwidthAction:
+		tc.Bandwidth = n.ToBandwidthSpec()
+	case NetworkReorderAction:
+		tc.Delay = &DelaySpec{
+			Latency:     n.Latency,
+			Correlation: "0",
+			Jitter:      "0ms",
+			Reorder:     n.toReorderSpec(),
+		}
+	case NetworkNetemAction:
+		if len(n.Latency) > 0 {
+			tc.Delay = &DelaySpec{
+				Latency:     n.Latency,
+				Correlation: n.Correlation,
+				Jitter:      n.Jitter,
+			}
+
+			if len(n.Reorder) > 0 {
+				tc.Delay.Reorder = n.toReorderSpec()
+			}
+		}
+
+		if len(n.Loss) > 0 {
+			tc.Loss = &LossSpec{
+				Loss:        n.Loss,
+				Correlation: n.LossCorrelation,
+			}
 		}
+
+		if len(n.Duplicate) > 0 {
+			tc.Duplicate = &DuplicateSpec{
+				Duplicate:   n.Duplicate,
+				Correlation: n.DuplicateCorrelation,
+			}
+		}
+
+		if len(n.Corrupt) > 0 {
+			tc.Corrupt = &CorruptSpec{
+				Corrupt:     n.Corrupt,
+				Correlation: n.CorruptCorrelation,
+			}
+		}
+	case NetworkProfileAction:
+		if n.NetworkProfile == nil {
+			return nil, errors.Errorf("network profile %s not resolved", n.Profile)
+		}
+
+		return n.NetworkProfile.ToTcParameter(n.Device), nil
 	default:
-		return nil, errors.Errorf("action %s not supported", n.Action)
+		return nil, errors.Errorf("network %s attack not supported", n.Action)
 	}
 
-	tc.Netem = netem
-
 	return tc, nil
 }
 
-func (n *NetworkCommand) ToIPSet(name string) (*pb.IPSet, error) {
+func (n *NetworkCommand) toReorderSpec() *ReorderSpec {
+	return &ReorderSpec{
+		Reorder:     n.Reorder,
+		Correlation: n.ReorderCorrelation,
+		Gap:         n.Gap,
+	}
+}
+
+// ToTCs converts the action to tc requests, the parameters of the tcs are returned to be recorded.
+// The action with both network emulation and bandwidth limit, such as profile action, is converted to two tcs.
+func (n *NetworkCommand) ToTCs(ipset string) ([]*pb.Tc, []*TcParameter, error) {
+	tcp, err := n.ToTcParameter()
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	params := tcp.Split()
+	tcs := make([]*pb.Tc, 0, len(params))
+	for _, param := range params {
+		tc := &pb.Tc{
+			Type:       pb.Tc_NETEM,
+			Ipset:      ipset,
+			Protocol:   n.IPProtocol,
+			SourcePort: n.SourcePort,
+			EgressPort: n.EgressPort,
+		}
+
+		if param.Bandwidth != nil {
+			tbf, err := param.Bandwidth.ToTbf()
+			if err != nil {
+				return nil, nil, errors.WithStack(err)
+			}
+
+			tc.Type = pb.Tc_BANDWIDTH
+			tc.Tbf = tbf
+		} else {
+			netem, err := toNetem(param)
+			if err != nil {
+				return nil, nil, errors.WithStack(err)
+			}
+
+			tc.Netem = netem
+		}
+
+		tcs = append(tcs, tc)
+	}
+
+	return tcs, params, nil
+}
+
+// ToIPSets resolves the IP addresses and hostnames, and converts them to the IPv4 ipset and IPv6 ipset.
+// The IPv4 ipset is always returned, it is empty if IPFamily is ipv6, so that the traffic control filtered
+// by it doesn't impact IPv4 traffic. The IPv6 ipset is nil if IPFamily is ipv4, or no IPv6 address
+// is resolved and IPFamily is empty, so that the host without IPv6 is not affected.
+func (n *NetworkCommand) ToIPSets(name string) (*pb.IPSet, *pb.IPSet, error) {
 	var (
 		cidrs []string
 		err   error
@@ -280,22 +706,38 @@ func (n *NetworkCommand) ToIPSet(name string) (*pb.IPSet, error) {
 	if len(n.IPAddress) > 0 {
 		cidrs, err = utils.ResolveCidrs(strings.Split(n.IPAddress, ","))
 		if err != nil {
-			return nil, errors.WithStack(err)
+			return nil, nil, errors.WithStack(err)
 		}
 	}
 
 	if len(n.Hostname) > 0 {
 		cs, err := utils.ResolveCidrs(strings.Split(n.Hostname, ","))
 		if err != nil {
-			return nil, errors.WithStack(err)
+			return nil, nil, errors.WithStack(err)
 		}
 		cidrs = append(cidrs, cs...)
 	}
 
-	return &pb.IPSet{
+	ipv4Cidrs, ipv6Cidrs := utils.SplitCidrsByFamily(cidrs)
+
+	if n.IPFamily == IPFamilyIPv6 {
+		ipv4Cidrs = nil
+	}
+
+	ipv4 := &pb.IPSet{
 		Name:  name,
-		Cidrs: cidrs,
-	}, nil
+		Cidrs: ipv4Cidrs,
+	}
+
+	var ipv6 *pb.IPSet
+	if n.IPFamily == IPFamilyIPv6 || (n.IPFamily != IPFamilyIPv4 && len(ipv6Cidrs) > 0) {
+		ipv6 = &pb.IPSet{
+			Name:  IPv6SetName(name),
+			Cidrs: ipv6Cidrs,
+		}
+	}
+
+	return ipv4, ipv6, nil
 }
 
 func (n *NetworkCommand) NeedApplyIPSet() bool {
@@ -312,13 +754,204 @@ func (n *NetworkCommand) NeedApplyIptables() bool {
 
 func (n *NetworkCommand) NeedApplyTC() bool {
 	switch n.Action {
-	case NetworkDelayAction, NetworkLossAction, NetworkCorruptAction, NetworkDuplicateAction:
+	case NetworkDelayAction, NetworkLossAction, NetworkCorruptAction, NetworkDuplicateAction,
+		NetworkBandwidthAction, NetworkReorderAction, NetworkNetemAction, NetworkProfileAction:
 		return true
 	default:
 		return false
 	}
 }
 
-func (n *NetworkCommand) ToChain() (*pb.Chain, error) {
-	return nil, nil
+// NeedApplyEgressTC returns true if the traffic control should be applied to the egress traffic of device.
+func (n *NetworkCommand) NeedApplyEgressTC() bool {
+	if !n.NeedApplyTC() {
+		return false
+	}
+
+	return n.Direction != DirectionIngress
+}
+
+// NeedApplyIngressTC returns true if the traffic control should be applied to the ingress traffic of device.
+func (n *NetworkCommand) NeedApplyIngressTC() bool {
+	if !n.NeedApplyTC() {
+		return false
+	}
+
+	return n.Direction == DirectionIngress || n.Direction == DirectionBoth
+}
+
+// ToIngressTCs converts the action to the tc requests applied on the IFB device,
+// the tcs have no filter since the traffic is filtered when it is redirected to the IFB device.
+func (n *NetworkCommand) ToIngressTCs() ([]*pb.Tc, []*TcParameter, error) {
+	tcs, params, err := n.ToTCs("")
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	for _, tc := range tcs {
+		tc.Protocol = ""
+		tc.SourcePort = ""
+		tc.EgressPort = ""
+	}
+
+	return tcs, params, nil
+}
+
+// IngressFilter is the filter which redirects the ingress traffic of the protocol to the IFB device
+type IngressFilter struct {
+	// Protocol is the protocol of the filter, such as ip, ipv6, all
+	Protocol string
+	// Match is the ematch expression of the filter, all the traffic of the protocol is redirected if it is empty
+	Match string
+}
+
+// IngressFilters returns the filters which redirect the ingress traffic,
+// ipset and ipset6 are the names of the IPv4 and IPv6 ipsets, they are empty if the ipsets are not created.
+func (n *NetworkCommand) IngressFilters(ipset string, ipset6 string) []IngressFilter {
+	if !n.NeedApplyIPSet() && len(n.IPFamily) == 0 && (len(n.IPProtocol) == 0 || n.IPProtocol == "all") &&
+		len(n.SourcePort) == 0 && len(n.EgressPort) == 0 {
+		return []IngressFilter{{Protocol: "all"}}
+	}
+
+	var filters []IngressFilter
+	if n.IPFamily != IPFamilyIPv6 && (!n.NeedApplyIPSet() || len(ipset) > 0) {
+		filters = append(filters, IngressFilter{Protocol: "ip", Match: n.ingressMatch(ipset, false)})
+	}
+
+	if n.IPFamily != IPFamilyIPv4 && (!n.NeedApplyIPSet() || len(ipset6) > 0) {
+		filters = append(filters, IngressFilter{Protocol: "ipv6", Match: n.ingressMatch(ipset6, true)})
+	}
+
+	return filters
+}
+
+// ingressMatch returns the ematch expression to match the ingress traffic.
+// The source port is the local port and the egress port is the remote port, the same as egress direction,
+// so they are matched by the destination port and source port of the ingress packets.
+// The protocol and ports are matched by the offsets in the IP header without options or extension headers.
+func (n *NetworkCommand) ingressMatch(ipset string, ipv6 bool) string {
+	protocolOffset, portOffset, icmp := 9, 20, 1
+	if ipv6 {
+		protocolOffset, portOffset, icmp = 6, 40, 58
+	}
+
+	var matches []string
+	if len(ipset) > 0 {
+		matches = append(matches, fmt.Sprintf("ipset(%s src)", ipset))
+	}
+
+	switch n.IPProtocol {
+	case "tcp":
+		matches = append(matches, fmt.Sprintf("cmp(u8 at %d layer network eq 6)", protocolOffset))
+	case "udp":
+		matches = append(matches, fmt.Sprintf("cmp(u8 at %d layer network eq 17)", protocolOffset))
+	case "icmp":
+		matches = append(matches, fmt.Sprintf("cmp(u8 at %d layer network eq %d)", protocolOffset, icmp))
+	}
+
+	if len(n.SourcePort) > 0 {
+		matches = append(matches, portsEmatch(portOffset+2, n.SourcePort))
+	}
+
+	if len(n.EgressPort) > 0 {
+		matches = append(matches, portsEmatch(portOffset, n.EgressPort))
+	}
+
+	return strings.Join(matches, " and ")
+}
+
+// portsEmatch returns the ematch expression of ports, offset is the offset of port in the network layer.
+func portsEmatch(offset int, ports string) string {
+	var matches []string
+	for _, port := range strings.Split(ports, ",") {
+		port = strings.TrimSpace(port)
+		if r := strings.Split(port, ":"); len(r) == 2 {
+			matches = append(matches, fmt.Sprintf(
+				"(not cmp(u16 at %d layer network lt %s) and not cmp(u16 at %d layer network gt %s))",
+				offset, r[0], offset, r[1]))
+			continue
+		}
+
+		matches = append(matches, fmt.Sprintf("cmp(u16 at %d layer network eq %s)", offset, port))
+	}
+
+	return fmt.Sprintf("(%s)", strings.Join(matches, " or "))
+}
+
+// ToChains converts the partition action to iptables chains,
+// the traffic matched by the chains will be dropped or rejected.
+func (n *NetworkCommand) ToChains(name string, ipset string) ([]*pb.Chain, error) {
+	if n.Action != NetworkPartitionAction {
+		return nil, nil
+	}
+
+	var directions []pb.Chain_Direction
+	switch n.Direction {
+	case DirectionTo:
+		directions = []pb.Chain_Direction{pb.Chain_OUTPUT}
+	case DirectionFrom:
+		directions = []pb.Chain_Direction{pb.Chain_INPUT}
+	case DirectionBoth:
+		directions = []pb.Chain_Direction{pb.Chain_OUTPUT, pb.Chain_INPUT}
+	default:
+		return nil, errors.Errorf("direction %s not supported", n.Direction)
+	}
+
+	target := "DROP"
+	if n.Reject {
+		target = "REJECT"
+	}
+
+	chains := make([]*pb.Chain, 0, len(directions))
+	for _, direction := range directions {
+		chain := &pb.Chain{
+			Name:      fmt.Sprintf("%s-in", name),
+			Direction: direction,
+			Target:    target,
+		}
+
+		// the traffic from the targets is matched by the source address in INPUT chain,
+		// and the traffic to the targets is matched by the destination address in OUTPUT chain.
+		matchPart := "src"
+		if direction == pb.Chain_OUTPUT {
+			chain.Name = fmt.Sprintf("%s-out", name)
+			matchPart = "dst"
+		}
+
+		var matches []string
+		if len(ipset) > 0 {
+			if n.AcceptList {
+				// only the traffic not matched by the ipset will be blocked
+				matches = append(matches, fmt.Sprintf("-m set ! --match-set %s %s", ipset, matchPart))
+			} else {
+				chain.Ipsets = []string{ipset}
+			}
+		}
+
+		if len(n.IPProtocol) > 0 {
+			matches = append(matches, fmt.Sprintf("--protocol %s", n.IPProtocol))
+		}
+		chain.Protocol = strings.Join(matches, " ")
+
+		if len(n.Port) > 0 {
+			if direction == pb.Chain_OUTPUT {
+				chain.DestinationPorts = portsMatch("destination", n.Port)
+			} else {
+				chain.SourcePorts = portsMatch("source", n.Port)
+			}
+		}
+
+		chains = append(chains, chain)
+	}
+
+	return chains, nil
+}
+
+// portsMatch returns the iptables matching part of ports, direction is source or destination.
+func portsMatch(direction string, ports string) string {
+	if strings.Contains(ports, ",") {
+		return fmt.Sprintf("-m multiport --%s-ports %s", direction, ports)
+	}
+
+	return fmt.Sprintf("--%s-port %s", direction, ports)
 }
@@ -0,0 +1,290 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/chaos-mesh/chaos-mesh/pkg/chaosdaemon/pb"
+)
+
+func TestToChains(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type TestCase struct {
+		name           string
+		cmd            *NetworkCommand
+		expectedChains []*pb.Chain
+	}
+
+	tcs := []TestCase{
+		{
+			name: "partition to targets",
+			cmd: &NetworkCommand{
+				Action:    NetworkPartitionAction,
+				Direction: DirectionTo,
+				IPAddress: "172.16.4.4",
+			},
+			expectedChains: []*pb.Chain{
+				{
+					Name:      "chaos-test-out",
+					Direction: pb.Chain_OUTPUT,
+					Ipsets:    []string{"chaos-test"},
+					Target:    "DROP",
+				},
+			},
+		},
+		{
+			name: "partition both directions with ports",
+			cmd: &NetworkCommand{
+				Action:     NetworkPartitionAction,
+				Direction:  DirectionBoth,
+				IPAddress:  "172.16.4.4",
+				IPProtocol: "tcp",
+				Port:       "80,443",
+				Reject:     true,
+			},
+			expectedChains: []*pb.Chain{
+				{
+					Name:             "chaos-test-out",
+					Direction:        pb.Chain_OUTPUT,
+					Ipsets:           []string{"chaos-test"},
+					Target:           "REJECT",
+					Protocol:         "--protocol tcp",
+					DestinationPorts: "-m multiport --destination-ports 80,443",
+				},
+				{
+					Name:        "chaos-test-in",
+					Direction:   pb.Chain_INPUT,
+					Ipsets:      []string{"chaos-test"},
+					Target:      "REJECT",
+					Protocol:    "--protocol tcp",
+					SourcePorts: "-m multiport --source-ports 80,443",
+				},
+			},
+		},
+		{
+			name: "partition with accept list",
+			cmd: &NetworkCommand{
+				Action:     NetworkPartitionAction,
+				Direction:  DirectionFrom,
+				IPAddress:  "172.16.4.4",
+				AcceptList: true,
+			},
+			expectedChains: []*pb.Chain{
+				{
+					Name:      "chaos-test-in",
+					Direction: pb.Chain_INPUT,
+					Target:    "DROP",
+					Protocol:  "-m set ! --match-set chaos-test src",
+				},
+			},
+		},
+		{
+			name: "not partition",
+			cmd: &NetworkCommand{
+				Action: NetworkDelayAction,
+			},
+			expectedChains: nil,
+		},
+	}
+
+	for _, tc := range tcs {
+		chains, err := tc.cmd.ToChains("chaos-test", "chaos-test")
+		g.Expect(err).ShouldNot(HaveOccurred(), tc.name)
+		g.Expect(chains).To(Equal(tc.expectedChains), tc.name)
+	}
+}
+
+func TestToTCNetem(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type TestCase struct {
+		name          string
+		cmd           *NetworkCommand
+		expectedNetem *pb.Netem
+	}
+
+	tcs := []TestCase{
+		{
+			name: "reorder",
+			cmd: &NetworkCommand{
+				Action:             NetworkReorderAction,
+				Device:             "eth0",
+				Latency:            "10ms",
+				Reorder:            "25",
+				ReorderCorrelation: "50",
+				Gap:                5,
+			},
+			expectedNetem: &pb.Netem{
+				Time:        10000,
+				Reorder:     25,
+				ReorderCorr: 50,
+				Gap:         5,
+			},
+		},
+		{
+			name: "netem without delay",
+			cmd: &NetworkCommand{
+				Action:               NetworkNetemAction,
+				Device:               "eth0",
+				Loss:                 "3",
+				LossCorrelation:      "0",
+				Corrupt:              "1",
+				CorruptCorrelation:   "20",
+				DuplicateCorrelation: "0",
+			},
+			expectedNetem: &pb.Netem{
+				Loss:        3,
+				Corrupt:     1,
+				CorruptCorr: 20,
+			},
+		},
+		{
+			name: "netem with all emulations",
+			cmd: &NetworkCommand{
+				Action:               NetworkNetemAction,
+				Device:               "eth0",
+				Latency:              "600ms",
+				Jitter:               "50ms",
+				Correlation:          "10",
+				Loss:                 "3",
+				LossCorrelation:      "0",
+				Duplicate:            "1",
+				DuplicateCorrelation: "0",
+				Corrupt:              "2",
+				CorruptCorrelation:   "0",
+				Reorder:              "5",
+				ReorderCorrelation:   "0",
+			},
+			expectedNetem: &pb.Netem{
+				Time:      600000,
+				Jitter:    50000,
+				DelayCorr: 10,
+				Loss:      3,
+				Duplicate: 1,
+				Corrupt:   2,
+				Reorder:   5,
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		g.Expect(tc.cmd.Validate()).ShouldNot(HaveOccurred(), tc.name)
+
+		result, _, err := tc.cmd.ToTCs("")
+		g.Expect(err).ShouldNot(HaveOccurred(), tc.name)
+		g.Expect(result).To(HaveLen(1), tc.name)
+		g.Expect(result[0].Type).To(Equal(pb.Tc_NETEM), tc.name)
+		g.Expect(result[0].Netem).To(Equal(tc.expectedNetem), tc.name)
+	}
+}
+
+func TestIngressFilters(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type TestCase struct {
+		name            string
+		cmd             *NetworkCommand
+		ipset           string
+		ipset6          string
+		expectedFilters []IngressFilter
+	}
+
+	tcs := []TestCase{
+		{
+			name:            "all traffic",
+			cmd:             &NetworkCommand{},
+			expectedFilters: []IngressFilter{{Protocol: "all"}},
+		},
+		{
+			name:            "IPv6 traffic",
+			cmd:             &NetworkCommand{IPFamily: IPFamilyIPv6},
+			expectedFilters: []IngressFilter{{Protocol: "ipv6"}},
+		},
+		{
+			name:            "traffic from IPv4 ipset",
+			cmd:             &NetworkCommand{IPAddress: "172.16.4.4", IPProtocol: "all"},
+			ipset:           "chaos-ipset",
+			expectedFilters: []IngressFilter{{Protocol: "ip", Match: "ipset(chaos-ipset src)"}},
+		},
+		{
+			name:   "traffic from both families",
+			cmd:    &NetworkCommand{Hostname: "example.com"},
+			ipset:  "chaos-ipset",
+			ipset6: "chaos-ipset-6",
+			expectedFilters: []IngressFilter{
+				{Protocol: "ip", Match: "ipset(chaos-ipset src)"},
+				{Protocol: "ipv6", Match: "ipset(chaos-ipset-6 src)"},
+			},
+		},
+		{
+			name: "traffic to local ports",
+			cmd: &NetworkCommand{
+				IPAddress:  "172.16.4.4",
+				IPProtocol: "tcp",
+				SourcePort: "80,8001:8010",
+			},
+			ipset: "chaos-ipset",
+			expectedFilters: []IngressFilter{{
+				Protocol: "ip",
+				Match: "ipset(chaos-ipset src) and cmp(u8 at 9 layer network eq 6) and " +
+					"(cmp(u16 at 22 layer network eq 80) or " +
+					"(not cmp(u16 at 22 layer network lt 8001) and not cmp(u16 at 22 layer network gt 8010)))",
+			}},
+		},
+		{
+			name: "traffic from remote port",
+			cmd: &NetworkCommand{
+				IPProtocol: "udp",
+				EgressPort: "53",
+			},
+			expectedFilters: []IngressFilter{
+				{Protocol: "ip", Match: "cmp(u8 at 9 layer network eq 17) and (cmp(u16 at 20 layer network eq 53))"},
+				{Protocol: "ipv6", Match: "cmp(u8 at 6 layer network eq 17) and (cmp(u16 at 40 layer network eq 53))"},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		g.Expect(tc.cmd.IngressFilters(tc.ipset, tc.ipset6)).To(Equal(tc.expectedFilters), tc.name)
+	}
+}
+
+func TestValidNetworkPartition(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type TestCase struct {
+		name        string
+		cmd         NetworkCommand
+		expectedErr bool
+	}
+
+	tcs := []TestCase{
+		{name: "ip", cmd: NetworkCommand{Action: NetworkPartitionAction, Direction: DirectionTo, IPAddress: "172.16.4.4"}},
+		{name: "port", cmd: NetworkCommand{Action: NetworkPartitionAction, Direction: DirectionBoth, Port: "80", IPProtocol: "tcp"}},
+		{name: "accept list without ip", cmd: NetworkCommand{Action: NetworkPartitionAction, Direction: DirectionBoth, Port: "80", IPProtocol: "tcp", AcceptList: true}, expectedErr: true},
+		{name: "all the traffic", cmd: NetworkCommand{Action: NetworkPartitionAction, Direction: DirectionFrom}, expectedErr: true},
+	}
+
+	for _, tc := range tcs {
+		if tc.expectedErr {
+			g.Expect(tc.cmd.Validate()).To(HaveOccurred(), tc.name)
+		} else {
+			g.Expect(tc.cmd.Validate()).NotTo(HaveOccurred(), tc.name)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// NetworkProfileBuiltin is the source of the built-in network profiles
+const NetworkProfileBuiltin = "builtin"
+
+// NetworkProfile is a named preset of network condition, such as the condition of 3G network.
+type NetworkProfile struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Source is builtin or the path of the file which defines the profile
+	Source string `json:"source,omitempty"`
+
+	Delay     *DelaySpec     `json:"delay,omitempty"`
+	Loss      *LossSpec      `json:"loss,omitempty"`
+	Duplicate *DuplicateSpec `json:"duplicate,omitempty"`
+	Corrupt   *CorruptSpec   `json:"corrupt,omitempty"`
+	Bandwidth *BandwidthSpec `json:"bandwidth,omitempty"`
+}
+
+var builtinNetworkProfiles = []*NetworkProfile{
+	{
+		Name:        "3g",
+		Description: "3G mobile network",
+		Delay:       &DelaySpec{Latency: "100ms", Jitter: "50ms", Correlation: "25"},
+		Loss:        &LossSpec{Loss: "1.5", Correlation: "25"},
+		Bandwidth:   &BandwidthSpec{Rate: "750kbit", Limit: 65536, Buffer: 16384},
+	},
+	{
+		Name:        "lte",
+		Description: "4G LTE mobile network",
+		Delay:       &DelaySpec{Latency: "50ms", Jitter: "10ms", Correlation: "25"},
+		Loss:        &LossSpec{Loss: "0.5", Correlation: "25"},
+		Bandwidth:   &BandwidthSpec{Rate: "12mbit", Limit: 262144, Buffer: 32768},
+	},
+	{
+		Name:        "transatlantic",
+		Description: "long distance link across the Atlantic Ocean",
+		Delay:       &DelaySpec{Latency: "80ms", Jitter: "5ms", Correlation: "25"},
+		Loss:        &LossSpec{Loss: "0.1", Correlation: "0"},
+	},
+	{
+		Name:        "satellite",
+		Description: "lossy geostationary satellite link",
+		Delay:       &DelaySpec{Latency: "600ms", Jitter: "50ms", Correlation: "25"},
+		Loss:        &LossSpec{Loss: "3", Correlation: "25"},
+		Bandwidth:   &BandwidthSpec{Rate: "2mbit", Limit: 524288, Buffer: 16384},
+	},
+	{
+		Name:        "flaky-wifi",
+		Description: "congested Wi-Fi with bursty loss and reordering",
+		Delay: &DelaySpec{
+			Latency:     "20ms",
+			Jitter:      "20ms",
+			Correlation: "50",
+			Reorder:     &ReorderSpec{Reorder: "1", Correlation: "0"},
+		},
+		Loss:      &LossSpec{Loss: "5", Correlation: "50"},
+		Duplicate: &DuplicateSpec{Duplicate: "0.5", Correlation: "0"},
+		Corrupt:   &CorruptSpec{Corrupt: "0.1", Correlation: "0"},
+		Bandwidth: &BandwidthSpec{Rate: "20mbit", Limit: 262144, Buffer: 32768},
+	},
+}
+
+// DeepCopy returns a copy of the profile which doesn't share any spec with it
+func (in *NetworkProfile) DeepCopy() *NetworkProfile {
+	out := *in
+	if in.Delay != nil {
+		delay := *in.Delay
+		if in.Delay.Reorder != nil {
+			reorder := *in.Delay.Reorder
+			delay.Reorder = &reorder
+		}
+		out.Delay = &delay
+	}
+	if in.Loss != nil {
+		loss := *in.Loss
+		out.Loss = &loss
+	}
+	if in.Duplicate != nil {
+		duplicate := *in.Duplicate
+		out.Duplicate = &duplicate
+	}
+	if in.Corrupt != nil {
+		corrupt := *in.Corrupt
+		out.Corrupt = &corrupt
+	}
+	if in.Bandwidth != nil {
+		bandwidth := *in.Bandwidth
+		if in.Bandwidth.Peakrate != nil {
+			peakrate := *in.Bandwidth.Peakrate
+			bandwidth.Peakrate = &peakrate
+		}
+		if in.Bandwidth.Minburst != nil {
+			minburst := *in.Bandwidth.Minburst
+			bandwidth.Minburst = &minburst
+		}
+		out.Bandwidth = &bandwidth
+	}
+
+	return &out
+}
+
+// LoadNetworkProfiles returns the built-in profiles and the profiles defined in the YAML files of dir,
+// the profile defined in dir overrides the built-in profile with the same name.
+// The profiles are sorted by name, and dir is ignored if it doesn't exist.
+func LoadNetworkProfiles(dir string) ([]*NetworkProfile, error) {
+	profiles := make(map[string]*NetworkProfile)
+	for _, p := range builtinNetworkProfiles {
+		// the built-in profile is copied, so that it is not modified by the caller
+		profile := p.DeepCopy()
+		profile.Source = NetworkProfileBuiltin
+		profiles[p.Name] = profile
+	}
+
+	if len(dir) > 0 {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, errors.WithStack(err)
+		}
+
+		for _, f := range files {
+			ext := filepath.Ext(f.Name())
+			if f.IsDir() || (ext != ".yaml" && ext != ".yml") {
+				continue
+			}
+
+			profile, err := loadNetworkProfile(filepath.Join(dir, f.Name()))
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			profiles[profile.Name] = profile
+		}
+	}
+
+	result := make([]*NetworkProfile, 0, len(profiles))
+	for _, p := range profiles {
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
+// FindNetworkProfile returns the profile with the name in the built-in profiles and the profiles defined in dir.
+func FindNetworkProfile(dir string, name string) (*NetworkProfile, error) {
+	profiles, err := LoadNetworkProfiles(dir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for _, p := range profiles {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+
+	return nil, errors.Errorf("network profile %s not found", name)
+}
+
+// loadNetworkProfile loads a profile from the YAML file, the name of the file is
+// used as the name of profile if it is not set.
+func loadNetworkProfile(file string) (*NetworkProfile, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	profile := &NetworkProfile{}
+	if err := yaml.UnmarshalStrict(data, profile); err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("failed to parse network profile %s", file))
+	}
+
+	if len(profile.Name) == 0 {
+		profile.Name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+	profile.Source = file
+	profile.setDefault()
+
+	if err := profile.Validate(); err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("network profile %s not valid", file))
+	}
+
+	return profile, nil
+}
+
+func (p *NetworkProfile) setDefault() {
+	if p.Delay != nil {
+		if len(p.Delay.Jitter) == 0 {
+			p.Delay.Jitter = "0ms"
+		}
+		if len(p.Delay.Correlation) == 0 {
+			p.Delay.Correlation = "0"
+		}
+		if p.Delay.Reorder != nil && len(p.Delay.Reorder.Correlation) == 0 {
+			p.Delay.Reorder.Correlation = "0"
+		}
+	}
+
+	if p.Loss != nil && len(p.Loss.Correlation) == 0 {
+		p.Loss.Correlation = "0"
+	}
+
+	if p.Duplicate != nil && len(p.Duplicate.Correlation) == 0 {
+		p.Duplicate.Correlation = "0"
+	}
+
+	if p.Corrupt != nil && len(p.Corrupt.Correlation) == 0 {
+		p.Corrupt.Correlation = "0"
+	}
+}
+
+// Validate checks whether the parameters of profile can be converted to traffic control.
+func (p *NetworkProfile) Validate() error {
+	if len(p.Name) == 0 {
+		return errors.New("name is required")
+	}
+
+	for _, tc := range p.ToTcParameter("").Split() {
+		if tc.Bandwidth != nil {
+			if _, err := tc.Bandwidth.ToTbf(); err != nil {
+				return errors.WithMessage(err, fmt.Sprintf("rate %s not valid", tc.Bandwidth.Rate))
+			}
+			continue
+		}
+
+		if _, err := toNetem(tc); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// ToTcParameter converts the profile to the parameters of traffic control on the device.
+func (p *NetworkProfile) ToTcParameter(device string) *TcParameter {
+	return &TcParameter{
+		Device:    device,
+		Delay:     p.Delay,
+		Loss:      p.Loss,
+		Duplicate: p.Duplicate,
+		Corrupt:   p.Corrupt,
+		Bandwidth: p.Bandwidth,
+	}
+}
+
+// Summary returns the brief description of the network condition.
+func (p *NetworkProfile) Summary() string {
+	var items []string
+	if p.Delay != nil {
+		items = append(items, fmt.Sprintf("latency=%s", p.Delay.Latency))
+		if len(p.Delay.Jitter) > 0 && p.Delay.Jitter != "0ms" {
+			items = append(items, fmt.Sprintf("jitter=%s", p.Delay.Jitter))
+		}
+		if p.Delay.Reorder != nil {
+			items = append(items, fmt.Sprintf("reorder=%s%%", p.Delay.Reorder.Reorder))
+		}
+	}
+	if p.Loss != nil {
+		items = append(items, fmt.Sprintf("loss=%s%%", p.Loss.Loss))
+	}
+	if p.Duplicate != nil {
+		items = append(items, fmt.Sprintf("duplicate=%s%%", p.Duplicate.Duplicate))
+	}
+	if p.Corrupt != nil {
+		items = append(items, fmt.Sprintf("corrupt=%s%%", p.Corrupt.Corrupt))
+	}
+	if p.Bandwidth != nil {
+		items = append(items, fmt.Sprintf("rate=%s", p.Bandwidth.Rate))
+	}
+
+	return strings.Join(items, " ")
+}
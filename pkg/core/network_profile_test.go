@@ -0,0 +1,90 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/chaos-mesh/chaos-mesh/pkg/chaosdaemon/pb"
+)
+
+func TestBuiltinNetworkProfiles(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for _, p := range builtinNetworkProfiles {
+		g.Expect(p.Validate()).ShouldNot(HaveOccurred(), p.Name)
+	}
+
+	// the loaded profiles are copies of the built-in profiles
+	profile, err := FindNetworkProfile("", "3g")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	profile.Delay.Latency = "1s"
+	profile.Bandwidth.Rate = "1kbit"
+
+	profile, err = FindNetworkProfile("", "3g")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(profile.Delay.Latency).To(Equal("100ms"))
+	g.Expect(profile.Bandwidth.Rate).To(Equal("750kbit"))
+}
+
+func TestLoadNetworkProfiles(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "network-profiles")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, "edge.yaml"), []byte(`
+delay:
+  latency: 30ms
+loss:
+  loss: "2"
+bandwidth:
+  rate: 5mbit
+  limit: 131072
+  buffer: 16384
+`), 0644)).To(Succeed())
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, "readme.txt"), []byte("not a profile"), 0644)).To(Succeed())
+
+	profile, err := FindNetworkProfile(dir, "edge")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(profile.Source).To(Equal(filepath.Join(dir, "edge.yaml")))
+
+	cmd := &NetworkCommand{
+		Action:         NetworkProfileAction,
+		Profile:        "edge",
+		Device:         "eth0",
+		NetworkProfile: profile,
+	}
+	tcs, params, err := cmd.ToTCs("")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(tcs).To(HaveLen(2))
+	g.Expect(params).To(HaveLen(2))
+	g.Expect(tcs[0].Type).To(Equal(pb.Tc_NETEM))
+	g.Expect(tcs[0].Netem).To(Equal(&pb.Netem{Time: 30000, Loss: 2}))
+	g.Expect(tcs[1].Type).To(Equal(pb.Tc_BANDWIDTH))
+	g.Expect(tcs[1].Tbf.Rate).To(Equal(uint64(625000)))
+
+	_, err = FindNetworkProfile(dir, "not-exist")
+	g.Expect(err).Should(HaveOccurred())
+
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, "broken.yml"), []byte("delay:\n  latency: fast\n"), 0644)).To(Succeed())
+	_, err = LoadNetworkProfiles(dir)
+	g.Expect(err).Should(HaveOccurred())
+}
@@ -17,6 +17,7 @@ import (
 	"context"
 	"encoding/json"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -31,6 +32,7 @@ import (
 type IPSetRuleStore interface {
 	List(ctx context.Context) ([]*IPSetRule, error)
 	Set(ctx context.Context, rule *IPSetRule) error
+	FindByContainer(ctx context.Context, container string) ([]*IPSetRule, error)
 	FindByExperiment(ctx context.Context, experiment string) ([]*IPSetRule, error)
 	DeleteByExperiment(ctx context.Context, experiment string) error
 }
@@ -41,10 +43,20 @@ type IPSetRule struct {
 	Name string `gorm:"index:name" json:"name"`
 	// The contents of ipset
 	Cidrs string `json:"cidrs"`
+	// The IP family of ipset, ipv4 or ipv6, empty means ipv4
+	Family string `json:"family"`
+	// Container is the container whose network namespace the rule is applied in, empty means the host.
+	Container string `gorm:"index:container" json:"container"`
 	// Experiment represents the experiment which the rule belong to.
 	Experiment string `gorm:"index:experiment" json:"experiment"`
 }
 
+// IPv6SetName returns the name of the IPv6 ipset which is created along with the IPv4 ipset,
+// since an ipset only contains the addresses of one family.
+func IPv6SetName(name string) string {
+	return name + "-6"
+}
+
 type Cidr struct {
 	gorm.Model
 	Cidr string
@@ -56,6 +68,7 @@ type ChainDirection string
 type IptablesRuleStore interface {
 	List(ctx context.Context) ([]*IptablesRule, error)
 	Set(ctx context.Context, rule *IptablesRule) error
+	FindByContainer(ctx context.Context, container string) ([]*IptablesRule, error)
 	FindByExperiment(ctx context.Context, experiment string) ([]*IptablesRule, error)
 	DeleteByExperiment(ctx context.Context, experiment string) error
 }
@@ -68,16 +81,38 @@ type IptablesRule struct {
 	IPSets string `json:"ipsets"`
 	// The block direction of this iptables rule
 	Direction string `json:"direction"`
+	// The target of this iptables rule, such as DROP, REJECT
+	Target string `json:"target"`
+	// The protocol matching part of this iptables rule
+	Protocol string `json:"protocol"`
+	// The source ports matching part of this iptables rule
+	SourcePorts string `json:"source_ports"`
+	// The destination ports matching part of this iptables rule
+	DestinationPorts string `json:"destination_ports"`
+	// The IP family of this rule, the IPv6 rule is set by ip6tables, empty means ipv4
+	Family string `json:"family"`
+	// Container is the container whose network namespace the rule is applied in, empty means the host.
+	Container string `gorm:"index:container" json:"container"`
 	// Experiment represents the experiment which the rule belong to.
 	Experiment string `gorm:"index:experiment" json:"experiment"`
 }
 
 func (i *IptablesRule) ToChain() *pb.Chain {
 	ch := &pb.Chain{
-		Name:      i.Name,
-		Ipsets:    strings.Split(i.IPSets, ","),
-		Direction: pb.Chain_Direction(pb.Chain_Direction_value[i.Direction]),
-		Target:    "DROP",
+		Name:             i.Name,
+		Direction:        pb.Chain_Direction(pb.Chain_Direction_value[i.Direction]),
+		Target:           i.Target,
+		Protocol:         i.Protocol,
+		SourcePorts:      i.SourcePorts,
+		DestinationPorts: i.DestinationPorts,
+	}
+
+	if len(i.IPSets) > 0 {
+		ch.Ipsets = strings.Split(i.IPSets, ",")
+	}
+
+	if len(ch.Target) == 0 {
+		ch.Target = "DROP"
 	}
 
 	return ch
@@ -85,6 +120,18 @@ func (i *IptablesRule) ToChain() *pb.Chain {
 
 type IptablesRuleList []*IptablesRule
 
+// ByFamily returns the rules of the IP family
+func (l IptablesRuleList) ByFamily(family string) IptablesRuleList {
+	rules := make(IptablesRuleList, 0, len(l))
+	for _, rule := range l {
+		if rule.Family == family || (len(rule.Family) == 0 && family == IPFamilyIPv4) {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules
+}
+
 func (l IptablesRuleList) ToChains() []*pb.Chain {
 	chains := make([]*pb.Chain, 0)
 
@@ -95,11 +142,39 @@ func (l IptablesRuleList) ToChains() []*pb.Chain {
 	return chains
 }
 
+type IFBRuleStore interface {
+	List(ctx context.Context) ([]*IFBRule, error)
+	Set(ctx context.Context, rule *IFBRule) error
+	FindByDevice(ctx context.Context, container string, device string) ([]*IFBRule, error)
+	FindByExperiment(ctx context.Context, experiment string) ([]*IFBRule, error)
+	DeleteByExperiment(ctx context.Context, experiment string) error
+}
+
+// IFBRule represents an IFB device which the ingress traffic of a device is redirected to,
+// the traffic control in ingress direction is applied on the egress of the IFB device.
+type IFBRule struct {
+	gorm.Model
+	// The name of IFB device
+	Name string `gorm:"index:name" json:"name"`
+	// The device whose ingress traffic is redirected
+	Device string `gorm:"index:device" json:"device"`
+	// The priority of the redirect filter on the ingress qdisc of the device
+	Priority uint32 `json:"priority"`
+	// The protocol of the redirect filter, such as ip, ipv6, all
+	Protocol string `json:"protocol"`
+	// The ematch expression of the redirect filter, all the traffic of the protocol is redirected if it is empty
+	Match string `json:"match"`
+	// Container is the container whose network namespace the rule is applied in, empty means the host.
+	Container string `gorm:"index:container" json:"container"`
+	// Experiment represents the experiment which the rule belong to.
+	Experiment string `gorm:"index:experiment" json:"experiment"`
+}
+
 type TCRuleStore interface {
 	List(ctx context.Context) ([]*TCRule, error)
 	ListGroupDevice(ctx context.Context) (map[string][]*TCRule, error)
 	Set(ctx context.Context, rule *TCRule) error
-	FindByDevice(ctx context.Context, experiment string) ([]*TCRule, error)
+	FindByDevice(ctx context.Context, container string, device string) ([]*TCRule, error)
 	FindByExperiment(ctx context.Context, experiment string) ([]*TCRule, error)
 	DeleteByExperiment(ctx context.Context, experiment string) error
 }
@@ -112,6 +187,8 @@ type TCRule struct {
 	TC   string `json:"tc"`
 	// The name of target ipset
 	IPSet string `json:"ipset,omitempty"`
+	// Container is the container whose network namespace the rule is applied in, empty means the host.
+	Container string `gorm:"index:container" json:"container"`
 	// Experiment represents the experiment which the rule belong to.
 	Experiment string `gorm:"index:experiment" json:"experiment"`
 
@@ -168,6 +245,13 @@ func (t TCRuleList) ToTCs() ([]*pb.Tc, error) {
 		tcs = append(tcs, tc)
 	}
 
+	// the netem qdiscs are placed before the tbf qdiscs, so that the tbf qdisc
+	// is attached as the child of the netem qdisc, which is the recommended way
+	// to combine the network emulation with the bandwidth limit.
+	sort.SliceStable(tcs, func(i, j int) bool {
+		return tcs[i].Type == pb.Tc_NETEM && tcs[j].Type != pb.Tc_NETEM
+	})
+
 	return tcs, nil
 }
 
@@ -186,6 +270,30 @@ type TcParameter struct {
 	Bandwidth *BandwidthSpec `json:"bandwidth,omitempty"`
 }
 
+// Split splits the parameter into the parameter of network emulation and the parameter of bandwidth limit,
+// since they are applied by different qdiscs and recorded in different tc rules.
+func (t *TcParameter) Split() []*TcParameter {
+	var tcs []*TcParameter
+	if t.Delay != nil || t.Loss != nil || t.Duplicate != nil || t.Corrupt != nil {
+		tcs = append(tcs, &TcParameter{
+			Device:    t.Device,
+			Delay:     t.Delay,
+			Loss:      t.Loss,
+			Duplicate: t.Duplicate,
+			Corrupt:   t.Corrupt,
+		})
+	}
+
+	if t.Bandwidth != nil {
+		tcs = append(tcs, &TcParameter{
+			Device:    t.Device,
+			Bandwidth: t.Bandwidth,
+		})
+	}
+
+	return tcs
+}
+
 // DelaySpec defines detail of a delay action
 type DelaySpec struct {
 	Latency     string       `json:"latency"`
@@ -317,6 +425,7 @@ func (in *CorruptSpec) ToNetem() (*pb.Netem, error) {
 // BandwidthSpec defines detail of bandwidth limit.
 type BandwidthSpec struct {
 	// Rate is the speed knob. Allows bps, kbps, mbps, gbps, tbps unit. bps means bytes per second.
+	// The bit based units bit, kbit, mbit, gbit, tbit are also allowed. bit means bits per second.
 	Rate string `json:"rate"`
 	// Limit is the number of bytes that can be queued waiting for tokens to become available.
 	Limit uint32 `json:"limit"`
@@ -384,6 +493,28 @@ func convertUnitToBytes(nu string) (uint64, error) {
 		}
 	}
 
+	// the bit based units follow the definition of tc, 1kbit is 1000 bits.
+	for i, u := range []string{"tbit", "gbit", "mbit", "kbit", "bit"} {
+		if strings.HasSuffix(s, u) {
+			ts := strings.TrimSuffix(s, u)
+			s := strings.TrimSpace(ts)
+
+			n, err := strconv.ParseUint(s, 10, 64)
+
+			if err != nil {
+				return 0, err
+			}
+
+			// convert unit to bits
+			for j := 4 - i; j > 0; j-- {
+				n = n * 1000
+			}
+
+			// convert bits to bytes
+			return n / 8, nil
+		}
+	}
+
 	return 0, errors.New("invalid unit")
 }
 
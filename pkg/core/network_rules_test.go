@@ -0,0 +1,75 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestConvertUnitToBytes(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type TestCase struct {
+		name          string
+		rate          string
+		expectedValue uint64
+		expectedErr   bool
+	}
+
+	tcs := []TestCase{
+		{
+			name:          "bytes per second",
+			rate:          "100bps",
+			expectedValue: 100,
+		},
+		{
+			name:          "megabytes per second",
+			rate:          "1mbps",
+			expectedValue: 1024 * 1024,
+		},
+		{
+			name:          "bits per second",
+			rate:          "800bit",
+			expectedValue: 100,
+		},
+		{
+			name:          "megabits per second",
+			rate:          "8 MBit",
+			expectedValue: 1000 * 1000,
+		},
+		{
+			name:        "invalid unit",
+			rate:        "1mb",
+			expectedErr: true,
+		},
+		{
+			name:        "invalid number",
+			rate:        "1.5mbps",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		rate, err := convertUnitToBytes(tc.rate)
+		if tc.expectedErr {
+			g.Expect(err).Should(HaveOccurred(), tc.name)
+			continue
+		}
+
+		g.Expect(err).ShouldNot(HaveOccurred(), tc.name)
+		g.Expect(rate).To(Equal(tc.expectedValue), tc.name)
+	}
+}
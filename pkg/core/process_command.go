@@ -14,9 +14,22 @@
 package core
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"math/rand"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/pingcap/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/chaos-mesh/chaosd/pkg/utils"
 )
 
 const (
@@ -28,26 +41,380 @@ type ProcessCommand struct {
 	Action string
 	// Process defines the process name or the process ID.
 	Process string
+	// Cmdline is the regular expression matched with the full command line of the process,
+	// whose arguments are joined by spaces.
+	Cmdline string
+	// User is the name or the UID of the user owning the process.
+	User string
+	// PPID is the ID of the parent process.
+	PPID int
+	// Cgroup is the cgroup path of the process, such as /system.slice/docker.service,
+	// the processes in the sub cgroups are matched too.
+	Cgroup string
+	// Newest and Oldest select the latest or the earliest started processes of the matched ones,
+	// only one process is selected if Count and Percent are not set.
+	Newest bool
+	Oldest bool
+	// Count and Percent select the count or the percent of the matched processes,
+	// the processes are selected randomly if neither Newest nor Oldest is set.
+	Count   int
+	Percent int
 	Signal  int
 	PIDs    []int
+	// Duration defines how long the attack lasts before it is recovered automatically.
+	Duration string
+	// Container is the container whose processes are attacked, such as docker://<id> or containerd://<id>,
+	// the processes are searched in the pid namespace of the container. Empty means the host.
+	Container string
+	// KillChildren sends the signal to all the descendants of the selected processes too
+	KillChildren bool
+	// Interval is the interval to send the signal repeatedly, the processes are selected again each time,
+	// so the new processes matching the selectors are attacked too. Empty means the signal is sent once.
+	Interval string
+	// Times is how many times the signal is sent if the Interval is set, 0 means until the attack is recovered.
+	Times int
+	// Restart restarts the killed processes when the attack is recovered, the processes are restarted with
+	// the command line, working directory, environment, user and resource limits captured before they are killed.
+	Restart bool
+	// Snapshots are the states of the killed processes captured for restarting them, they contain the environment
+	// of the processes, so they are kept in the ProcessSnapshotStore instead of the recover command.
+	Snapshots []*ProcessSnapshot `json:"-"`
 	// TODO: support these feature
-	// Newest       bool
-	// Oldest       bool
 	// Exact        bool
-	// KillChildren bool
-	// User         string
+}
+
+// ProcessSnapshotStore defines operations for working with the snapshots of the processes killed by the process attacks
+type ProcessSnapshotStore interface {
+	ListByExperiment(ctx context.Context, experiment string) ([]*ProcessSnapshot, error)
+	// Set creates or updates the snapshot of the process killed by the experiment
+	Set(ctx context.Context, experiment string, snapshot *ProcessSnapshot) error
+}
+
+// ProcessSnapshot is the state of a process captured before it is killed, the process is restarted with it.
+type ProcessSnapshot struct {
+	Pid     int      `json:"pid"`
+	Args    []string `json:"args"`
+	Cwd     string   `json:"cwd"`
+	Env     []string `json:"env"`
+	Uid     uint32   `json:"uid"`
+	Gid     uint32   `json:"gid"`
+	Groups  []uint32 `json:"groups"`
+	Rlimits []Rlimit `json:"rlimits"`
+	// RestartedPid is the pid of the restarted process
+	RestartedPid int `json:"restarted_pid,omitempty"`
+}
+
+// Rlimit is the resource limit of a process
+type Rlimit struct {
+	Resource int    `json:"resource"`
+	Cur      uint64 `json:"cur"`
+	Max      uint64 `json:"max"`
+}
+
+// ProcessInfo represents a process matched by the process attack
+type ProcessInfo struct {
+	Pid  int `json:"pid"`
+	PPid int `json:"ppid"`
+	// NsPid is the pid in the pid namespace of the container
+	NsPid int `json:"ns_pid,omitempty"`
+	Uid   int `json:"uid"`
+	// Name is the executable name of the process, which is truncated to 15 characters by the kernel
+	Name    string `json:"name"`
+	Cmdline string `json:"cmdline"`
+	// StartTime is the time the process started after the system boot, in clock ticks
+	StartTime uint64   `json:"start_time"`
+	Cgroups   []string `json:"cgroups,omitempty"`
 }
 
 func (p *ProcessCommand) Validate() error {
-	if len(p.Process) == 0 {
-		return errors.New("process not provided")
+	if len(p.Process) == 0 && len(p.Cmdline) == 0 && len(p.User) == 0 && p.PPID == 0 && len(p.Cgroup) == 0 {
+		return errors.New("process not provided, at least one of process, cmdline, user, ppid and cgroup is required")
+	}
+
+	if len(p.Cmdline) > 0 {
+		if _, err := regexp.Compile(p.Cmdline); err != nil {
+			return errors.Errorf("cmdline %s is not a valid regular expression: %s", p.Cmdline, err)
+		}
+	}
+
+	if p.PPID < 0 {
+		return errors.Errorf("ppid %d not valid", p.PPID)
+	}
+
+	if p.Newest && p.Oldest {
+		return errors.New("newest and oldest can not be set at the same time")
+	}
+
+	if p.Count < 0 {
+		return errors.Errorf("count %d should not be negative", p.Count)
+	}
+
+	if p.Percent < 0 || p.Percent > 100 {
+		return errors.Errorf("percent %d should be in [0, 100]", p.Percent)
+	}
+
+	if p.Count > 0 && p.Percent > 0 {
+		return errors.New("count and percent can not be set at the same time")
+	}
+
+	if err := validContainer(p.Container); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if !ValidSignal(p.Signal) {
+		return errors.Errorf("signal %d is not valid, it should be in [1, %d]", p.Signal, maxSignal)
+	}
+
+	if !utils.CheckDuration(p.Duration) {
+		return errors.Errorf("duration %s not valid", p.Duration)
 	}
 
-	// TODO: validate signal
+	if len(p.Interval) > 0 {
+		if interval, err := time.ParseDuration(p.Interval); err != nil || interval <= 0 {
+			return errors.Errorf("interval %s not valid", p.Interval)
+		}
+
+		if p.Stops() {
+			return errors.Errorf("interval is not supported by %s, the stopped processes can't be stopped again", SignalName(p.Signal))
+		}
+	}
+
+	if p.Restart {
+		if p.Stops() {
+			return errors.Errorf("restart is not supported by %s, the stopped processes are continued when recovered", SignalName(p.Signal))
+		}
+
+		if p.Repeated() {
+			return errors.New("restart is not supported with interval")
+		}
+
+		if len(p.Container) > 0 {
+			return errors.New("restart is not supported in the container")
+		}
+	}
+
+	if p.Times < 0 {
+		return errors.Errorf("times %d should not be negative", p.Times)
+	}
+
+	if p.Times > 0 && len(p.Interval) == 0 {
+		return errors.New("times is only supported with interval")
+	}
+
+	if len(p.Duration) > 0 && !p.Recoverable() {
+		return errors.Errorf("duration is not supported by %s, the processes can't be recovered", SignalName(p.Signal))
+	}
 
 	return nil
 }
 
+// Stops returns true if the signal stops the processes, they can be recovered by SIGCONT.
+func (p *ProcessCommand) Stops() bool {
+	switch syscall.Signal(p.Signal) {
+	case syscall.SIGSTOP, syscall.SIGTSTP, syscall.SIGTTIN, syscall.SIGTTOU:
+		return true
+	}
+
+	return false
+}
+
+// Repeated returns true if the signal is sent repeatedly
+func (p *ProcessCommand) Repeated() bool {
+	return len(p.Interval) > 0
+}
+
+// Recoverable returns true if the attack can be recovered, the stopped processes are continued,
+// the repeated signals are stopped and the killed processes are restarted if Restart is set.
+// The processes received other signals can't be recovered.
+func (p *ProcessCommand) Recoverable() bool {
+	return p.Stops() || p.Repeated() || p.Restart
+}
+
+// maxSignal is the max signal number on Linux, the signals from SIGRTMIN are real-time signals
+const maxSignal = 64
+
+// ValidSignal returns true if the signal can be sent to the processes
+func ValidSignal(sig int) bool {
+	return sig > 0 && sig <= maxSignal
+}
+
+// ParseSignal parses the signal by the number or the name, the name is case-insensitive
+// and the SIG prefix can be omitted, such as 9, SIGKILL, kill.
+func ParseSignal(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if sig, err := strconv.Atoi(s); err == nil {
+		if !ValidSignal(sig) {
+			return 0, errors.Errorf("signal %d is not valid, it should be in [1, %d]", sig, maxSignal)
+		}
+		return sig, nil
+	}
+
+	name := strings.ToUpper(s)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+
+	sig := unix.SignalNum(name)
+	if sig == 0 {
+		return 0, errors.Errorf("signal %s is not valid", s)
+	}
+
+	return int(sig), nil
+}
+
+// SignalName returns the name of the signal, such as SIGKILL
+func SignalName(sig int) string {
+	if name := unix.SignalName(syscall.Signal(sig)); len(name) > 0 {
+		return name
+	}
+
+	return fmt.Sprintf("signal %d", sig)
+}
+
+// ProcessSelector matches the processes with the selectors of the attack,
+// the regular expression of Cmdline is compiled once for all the processes.
+type ProcessSelector struct {
+	attack  *ProcessCommand
+	uid     int
+	cmdline *regexp.Regexp
+}
+
+// NewSelector returns the selector of the attack, uid is the resolved UID of the User.
+func (p *ProcessCommand) NewSelector(uid int) (*ProcessSelector, error) {
+	selector := &ProcessSelector{attack: p, uid: uid}
+	if len(p.Cmdline) > 0 {
+		cmdline, err := regexp.Compile(p.Cmdline)
+		if err != nil {
+			return nil, errors.Errorf("cmdline %s is not a valid regular expression: %s", p.Cmdline, err)
+		}
+		selector.cmdline = cmdline
+	}
+
+	return selector, nil
+}
+
+// Matches returns true if the process matches all the selectors
+func (s *ProcessSelector) Matches(info *ProcessInfo) bool {
+	p := s.attack
+	if len(p.Process) > 0 {
+		pid := info.Pid
+		if info.NsPid > 0 {
+			pid = info.NsPid
+		}
+
+		if p.Process != strconv.Itoa(pid) && p.Process != info.Name && p.Process != executable(info.Cmdline) {
+			return false
+		}
+	}
+
+	if s.cmdline != nil && !s.cmdline.MatchString(info.Cmdline) {
+		return false
+	}
+
+	if len(p.User) > 0 && info.Uid != s.uid {
+		return false
+	}
+
+	if p.PPID > 0 && info.PPid != p.PPID {
+		return false
+	}
+
+	if len(p.Cgroup) > 0 && !inCgroup(info.Cgroups, p.Cgroup) {
+		return false
+	}
+
+	return true
+}
+
+// Sample selects the processes by Newest, Oldest, Count and Percent
+func (p *ProcessCommand) Sample(infos []*ProcessInfo, r *rand.Rand) []*ProcessInfo {
+	count := len(infos)
+	switch {
+	case p.Count > 0:
+		count = p.Count
+	case p.Percent > 0:
+		count = (len(infos)*p.Percent + 99) / 100
+	case p.Newest || p.Oldest:
+		count = 1
+	}
+
+	if count >= len(infos) {
+		return infos
+	}
+
+	selected := make([]*ProcessInfo, len(infos))
+	copy(selected, infos)
+
+	// the start time is in clock ticks, the processes started in the same tick are ordered by pid
+	switch {
+	case p.Newest:
+		sort.SliceStable(selected, func(i, j int) bool {
+			if selected[i].StartTime != selected[j].StartTime {
+				return selected[i].StartTime > selected[j].StartTime
+			}
+			return selected[i].Pid > selected[j].Pid
+		})
+	case p.Oldest:
+		sort.SliceStable(selected, func(i, j int) bool {
+			if selected[i].StartTime != selected[j].StartTime {
+				return selected[i].StartTime < selected[j].StartTime
+			}
+			return selected[i].Pid < selected[j].Pid
+		})
+	default:
+		r.Shuffle(len(selected), func(i, j int) {
+			selected[i], selected[j] = selected[j], selected[i]
+		})
+	}
+
+	return selected[:count]
+}
+
+// Selector returns the description of the selectors, it is used in the messages
+func (p *ProcessCommand) Selector() string {
+	if len(p.Process) > 0 {
+		return p.Process
+	}
+
+	selectors := make([]string, 0)
+	if len(p.Cmdline) > 0 {
+		selectors = append(selectors, "cmdline "+p.Cmdline)
+	}
+	if len(p.User) > 0 {
+		selectors = append(selectors, "user "+p.User)
+	}
+	if p.PPID > 0 {
+		selectors = append(selectors, "ppid "+strconv.Itoa(p.PPID))
+	}
+	if len(p.Cgroup) > 0 {
+		selectors = append(selectors, "cgroup "+p.Cgroup)
+	}
+
+	return "with " + strings.Join(selectors, ", ")
+}
+
+// executable returns the name of the executable in the command line
+func executable(cmdline string) string {
+	fields := strings.Fields(cmdline)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	return path.Base(fields[0])
+}
+
+// inCgroup returns true if one of the cgroups is the cgroup or its sub cgroup
+func inCgroup(cgroups []string, cgroup string) bool {
+	cgroup = strings.TrimSuffix(cgroup, "/")
+	for _, cg := range cgroups {
+		if cg == cgroup || strings.HasPrefix(cg, cgroup+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (p *ProcessCommand) String() string {
 	data, _ := json.Marshal(p)
 
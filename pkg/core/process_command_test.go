@@ -0,0 +1,157 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestProcessMatches(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	info := &ProcessInfo{
+		Pid:     100,
+		PPid:    1,
+		NsPid:   100,
+		Uid:     1000,
+		Name:    "java",
+		Cmdline: "/usr/bin/java -Xmx1g -jar worker.jar",
+		Cgroups: []string{"/system.slice/worker.service"},
+	}
+
+	type TestCase struct {
+		name     string
+		attack   ProcessCommand
+		uid      int
+		expected bool
+	}
+
+	tcs := []TestCase{
+		{name: "pid", attack: ProcessCommand{Process: "100"}, expected: true},
+		{name: "executable name", attack: ProcessCommand{Process: "java"}, expected: true},
+		{name: "another name", attack: ProcessCommand{Process: "python"}, expected: false},
+		{name: "cmdline", attack: ProcessCommand{Cmdline: "java .*-jar worker"}, expected: true},
+		{name: "cmdline not matched", attack: ProcessCommand{Cmdline: "^python"}, expected: false},
+		{name: "user", attack: ProcessCommand{User: "1000"}, uid: 1000, expected: true},
+		{name: "another user", attack: ProcessCommand{User: "root"}, uid: 0, expected: false},
+		{name: "ppid", attack: ProcessCommand{PPID: 1}, expected: true},
+		{name: "another ppid", attack: ProcessCommand{PPID: 2}, expected: false},
+		{name: "cgroup", attack: ProcessCommand{Cgroup: "/system.slice/"}, expected: true},
+		{name: "cgroup prefix of the name", attack: ProcessCommand{Cgroup: "/system.slice/work"}, expected: false},
+		{name: "all selectors", attack: ProcessCommand{Process: "java", Cmdline: "worker", PPID: 1}, expected: true},
+	}
+
+	for _, tc := range tcs {
+		selector, err := tc.attack.NewSelector(tc.uid)
+		g.Expect(err).ShouldNot(HaveOccurred(), tc.name)
+		g.Expect(selector.Matches(info)).To(Equal(tc.expected), tc.name)
+	}
+}
+
+func TestProcessSample(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	infos := make([]*ProcessInfo, 0)
+	for i := 1; i <= 10; i++ {
+		infos = append(infos, &ProcessInfo{Pid: i, StartTime: uint64(100 + i)})
+	}
+
+	pidsOf := func(infos []*ProcessInfo) []int {
+		pids := make([]int, 0)
+		for _, info := range infos {
+			pids = append(pids, info.Pid)
+		}
+		return pids
+	}
+
+	r := rand.New(rand.NewSource(1))
+
+	g.Expect(pidsOf((&ProcessCommand{}).Sample(infos, r))).To(HaveLen(10))
+	g.Expect(pidsOf((&ProcessCommand{Newest: true}).Sample(infos, r))).To(Equal([]int{10}))
+	g.Expect(pidsOf((&ProcessCommand{Oldest: true, Count: 2}).Sample(infos, r))).To(Equal([]int{1, 2}))
+	g.Expect((&ProcessCommand{Count: 3}).Sample(infos, r)).To(HaveLen(3))
+	g.Expect((&ProcessCommand{Percent: 25}).Sample(infos, r)).To(HaveLen(3))
+	g.Expect((&ProcessCommand{Count: 20}).Sample(infos, r)).To(HaveLen(10))
+	// the matched processes are not changed
+	g.Expect(pidsOf(infos)).To(Equal([]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}))
+}
+
+func TestParseSignal(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type TestCase struct {
+		signal      string
+		expected    int
+		expectedErr bool
+	}
+
+	tcs := []TestCase{
+		{signal: "9", expected: 9},
+		{signal: "SIGHUP", expected: 1},
+		{signal: "usr1", expected: 10},
+		{signal: "Quit", expected: 3},
+		{signal: "SIGRTMIN", expectedErr: true},
+		{signal: "0", expectedErr: true},
+		{signal: "65", expectedErr: true},
+		{signal: "FOO", expectedErr: true},
+	}
+
+	for _, tc := range tcs {
+		sig, err := ParseSignal(tc.signal)
+		if tc.expectedErr {
+			g.Expect(err).To(HaveOccurred(), tc.signal)
+			continue
+		}
+
+		g.Expect(err).NotTo(HaveOccurred(), tc.signal)
+		g.Expect(sig).To(Equal(tc.expected), tc.signal)
+	}
+}
+
+func TestProcessValidate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type TestCase struct {
+		name        string
+		attack      ProcessCommand
+		expectedErr bool
+	}
+
+	tcs := []TestCase{
+		{name: "kill", attack: ProcessCommand{Process: "java", Signal: 9}},
+		{name: "stop with duration", attack: ProcessCommand{Process: "java", Signal: 19, Duration: "1m"}},
+		{name: "kill with duration", attack: ProcessCommand{Process: "java", Signal: 9, Duration: "1m"}, expectedErr: true},
+		{name: "repeated kill", attack: ProcessCommand{Process: "java", Signal: 9, Interval: "1s", Times: 3}},
+		{name: "repeated kill with duration", attack: ProcessCommand{Process: "java", Signal: 9, Interval: "1s", Duration: "1m"}},
+		{name: "repeated stop", attack: ProcessCommand{Process: "java", Signal: 19, Interval: "1s"}, expectedErr: true},
+		{name: "invalid interval", attack: ProcessCommand{Process: "java", Signal: 9, Interval: "0s"}, expectedErr: true},
+		{name: "times without interval", attack: ProcessCommand{Process: "java", Signal: 9, Times: 3}, expectedErr: true},
+		{name: "kill and restart", attack: ProcessCommand{Process: "java", Signal: 9, Restart: true}},
+		{name: "stop and restart", attack: ProcessCommand{Process: "java", Signal: 19, Restart: true}, expectedErr: true},
+		{name: "repeated restart", attack: ProcessCommand{Process: "java", Signal: 9, Interval: "1s", Restart: true}, expectedErr: true},
+		{name: "no selector", attack: ProcessCommand{Signal: 9}, expectedErr: true},
+		{name: "invalid signal", attack: ProcessCommand{Process: "java"}, expectedErr: true},
+	}
+
+	for _, tc := range tcs {
+		if tc.expectedErr {
+			g.Expect(tc.attack.Validate()).To(HaveOccurred(), tc.name)
+		} else {
+			g.Expect(tc.attack.Validate()).NotTo(HaveOccurred(), tc.name)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// ProcessEventStore defines operations for working with the signals sent by the process attacks
+type ProcessEventStore interface {
+	ListByExperiment(ctx context.Context, experiment string) ([]*ProcessEvent, error)
+	Set(ctx context.Context, event *ProcessEvent) error
+}
+
+// ProcessEvent represents a signal sent to a process by the process attack,
+// it can be correlated with the logs of the application by the time and the pid.
+type ProcessEvent struct {
+	ID         uint   `gorm:"primary_key" json:"id"`
+	Experiment string `gorm:"index:process_event_experiment" json:"experiment"`
+	// Round is the times the signal has been sent by the experiment, starting from 1
+	Round   int       `json:"round"`
+	Pid     int       `json:"pid"`
+	Cmdline string    `json:"cmdline"`
+	Signal  int       `json:"signal"`
+	Time    time.Time `json:"time"`
+}
@@ -0,0 +1,42 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+const (
+	// ReconcileReapply means the rules of the experiment are lost on the host and will be applied again.
+	ReconcileReapply = "reapply"
+	// ReconcileRecover means the experiment was interrupted while being applied,
+	// the partially applied chaos will be recovered and the experiment will be marked as error.
+	ReconcileRecover = "recover"
+	// ReconcileMarkError means the experiment can not be restored, it will be marked as error.
+	ReconcileMarkError = "mark-error"
+)
+
+type ReconcileCommand struct {
+	// DryRun only reports the operations without performing them
+	DryRun bool
+}
+
+// ReconcileResult represents the operation performed on an orphaned experiment.
+type ReconcileResult struct {
+	Uid       string `json:"uid"`
+	Kind      string `json:"kind"`
+	Action    string `json:"action"`
+	Status    string `json:"status"`
+	Operation string `json:"operation"`
+	// Message explains why the operation is needed
+	Message string `json:"message"`
+	// Error is the error occurred while checking the experiment or performing the operation
+	Error string `json:"error,omitempty"`
+}
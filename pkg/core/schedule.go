@@ -0,0 +1,234 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/robfig/cron/v3"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ConcurrencyForbid skips the run if the experiment created by the last run is still running
+	ConcurrencyForbid = "forbid"
+	// ConcurrencyAllow allows the experiments of the schedule to run at the same time
+	ConcurrencyAllow = "allow"
+	// ConcurrencyReplace recovers the running experiments before creating the new one
+	ConcurrencyReplace = "replace"
+)
+
+// DefaultHistoryLimit is the count of the finished experiments kept for a schedule by default
+const DefaultHistoryLimit = 10
+
+// ScheduleStore defines operations for working with schedules
+type ScheduleStore interface {
+	List(ctx context.Context) ([]*Schedule, error)
+	FindByUid(ctx context.Context, uid string) (*Schedule, error)
+	Set(ctx context.Context, schedule *Schedule) error
+	Delete(ctx context.Context, uid string) error
+}
+
+// Schedule represents a schedule which creates experiments periodically.
+type Schedule struct {
+	ID        uint      `gorm:"primary_key" json:"id"`
+	Uid       string    `gorm:"index:schedule_uid" json:"uid"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+	Paused    bool      `json:"paused"`
+	// LastScheduleTime is the time when an experiment was created by the schedule last time
+	LastScheduleTime *time.Time `json:"last_schedule_time,omitempty"`
+	// Spec is the original spec of the schedule in JSON
+	Spec string `json:"spec"`
+}
+
+// ScheduleStatus represents a schedule and the experiments created by it
+type ScheduleStatus struct {
+	*Schedule
+	// NextScheduleTime is empty if the schedule is paused
+	NextScheduleTime *time.Time    `json:"next_schedule_time,omitempty"`
+	Experiments      []*Experiment `json:"experiments,omitempty"`
+}
+
+// ScheduleSpec is the declarative format of a schedule, for example, inject 5% packet loss for 10 minutes
+// at 14:00 on every weekday:
+//
+//	name: weekday-loss
+//	cron: "0 14 * * 1-5"
+//	duration: 10m
+//	concurrencyPolicy: forbid
+//	startingDeadline: 5m
+//	kind: network
+//	spec:
+//	  action: loss
+//	  device: eth0
+//	  percent: 5
+type ScheduleSpec struct {
+	Name string `json:"name"`
+	// Cron is the standard cron expression, the time zone can be specified by the prefix CRON_TZ=
+	Cron string `json:"cron"`
+	// Duration is how long each experiment lasts, empty means the experiment is not recovered automatically
+	Duration string `json:"duration,omitempty"`
+	// ConcurrencyPolicy is one of forbid, allow and replace, the default policy is forbid
+	ConcurrencyPolicy string `json:"concurrencyPolicy,omitempty"`
+	// HistoryLimit is the count of the finished experiments kept for the schedule
+	HistoryLimit *int `json:"historyLimit,omitempty"`
+	// StartingDeadline is how late the missed run can be started, such as the run missed while chaosd server is down.
+	// The missed runs are skipped if it is empty.
+	StartingDeadline string `json:"startingDeadline,omitempty"`
+
+	// Kind and Spec describe the experiment created by the schedule, the same as ExperimentSpec.
+	Kind string          `json:"kind"`
+	Spec json.RawMessage `json:"spec"`
+}
+
+// ParseScheduleSpec parses the schedule spec in YAML or JSON
+func ParseScheduleSpec(data []byte) (*ScheduleSpec, error) {
+	spec := &ScheduleSpec{}
+	if err := yaml.Unmarshal(data, spec); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return spec, nil
+}
+
+func (s *ScheduleSpec) SetDefault() {
+	if len(s.ConcurrencyPolicy) == 0 {
+		s.ConcurrencyPolicy = ConcurrencyForbid
+	}
+
+	if s.HistoryLimit == nil {
+		limit := DefaultHistoryLimit
+		s.HistoryLimit = &limit
+	}
+}
+
+func (s *ScheduleSpec) Validate() error {
+	if len(s.Name) == 0 {
+		return errors.New("name is required")
+	}
+
+	if _, err := s.Schedule(); err != nil {
+		return err
+	}
+
+	switch s.ConcurrencyPolicy {
+	case ConcurrencyForbid, ConcurrencyAllow, ConcurrencyReplace:
+	default:
+		return errors.Errorf("concurrency policy %s not supported", s.ConcurrencyPolicy)
+	}
+
+	if s.HistoryLimit != nil && *s.HistoryLimit < 0 {
+		return errors.Errorf("history limit %d should not be negative", *s.HistoryLimit)
+	}
+
+	if len(s.StartingDeadline) > 0 {
+		if _, err := s.StartingDeadlineTime(); err != nil {
+			return err
+		}
+	}
+
+	cmd, err := s.Command()
+	if err != nil {
+		return err
+	}
+
+	return cmd.Validate()
+}
+
+// Schedule parses the cron expression
+func (s *ScheduleSpec) Schedule() (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(s.Cron)
+	if err != nil {
+		return nil, errors.Errorf("cron %q not valid: %s", s.Cron, err)
+	}
+
+	return schedule, nil
+}
+
+// StartingDeadlineTime returns the starting deadline, 0 means the missed runs are skipped
+func (s *ScheduleSpec) StartingDeadlineTime() (time.Duration, error) {
+	if len(s.StartingDeadline) == 0 {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(s.StartingDeadline)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	if d < 0 {
+		return 0, errors.Errorf("starting deadline %s should not be negative", s.StartingDeadline)
+	}
+
+	return d, nil
+}
+
+// MissedRun returns the latest run missed between the last schedule time and now,
+// false is returned if no run is missed or the starting deadline of the missed run is exceeded.
+func (s *ScheduleSpec) MissedRun(last, now time.Time) (time.Time, bool) {
+	deadline, err := s.StartingDeadlineTime()
+	if err != nil || deadline == 0 {
+		return time.Time{}, false
+	}
+
+	schedule, err := s.Schedule()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var missed time.Time
+	for t := schedule.Next(last); !t.IsZero() && !t.After(now); t = schedule.Next(t) {
+		missed = t
+	}
+
+	if missed.IsZero() || now.Sub(missed) > deadline {
+		return time.Time{}, false
+	}
+
+	return missed, true
+}
+
+// Command decodes the spec into the command of the kind, the duration of the schedule is set to the command,
+// so that the experiment is recovered by the timer of chaosd server.
+func (s *ScheduleSpec) Command() (AttackCommand, error) {
+	cmd, err := (&ExperimentSpec{Name: s.Name, Kind: s.Kind, Spec: s.Spec}).Command()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(s.Duration) == 0 {
+		return cmd, nil
+	}
+
+	switch attack := cmd.(type) {
+	case *ProcessCommand:
+		attack.Duration = s.Duration
+	case *NetworkCommand:
+		attack.Duration = s.Duration
+	case *StressCommand:
+		attack.Duration = s.Duration
+	}
+
+	return cmd, nil
+}
+
+func (s *ScheduleSpec) String() string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
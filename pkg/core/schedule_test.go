@@ -0,0 +1,82 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestMissedRun(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type TestCase struct {
+		name             string
+		startingDeadline string
+		last             string
+		now              string
+		expectedMissed   string
+	}
+
+	tcs := []TestCase{
+		{
+			name:             "the latest missed run is within the deadline",
+			startingDeadline: "30m",
+			last:             "2021-01-04T14:00:00Z",
+			now:              "2021-01-06T14:10:00Z",
+			expectedMissed:   "2021-01-06T14:00:00Z",
+		},
+		{
+			name:             "the deadline is exceeded",
+			startingDeadline: "5m",
+			last:             "2021-01-04T14:00:00Z",
+			now:              "2021-01-06T14:10:00Z",
+		},
+		{
+			name:             "the missed runs are skipped without deadline",
+			startingDeadline: "",
+			last:             "2021-01-04T14:00:00Z",
+			now:              "2021-01-06T14:10:00Z",
+		},
+		{
+			name:             "no run is missed",
+			startingDeadline: "30m",
+			last:             "2021-01-06T14:00:00Z",
+			now:              "2021-01-06T14:10:00Z",
+		},
+		{
+			name:             "the runs are missed on weekend",
+			startingDeadline: "72h",
+			last:             "2021-01-07T14:00:00Z",
+			now:              "2021-01-11T13:00:00Z",
+			expectedMissed:   "2021-01-08T14:00:00Z",
+		},
+	}
+
+	for _, tc := range tcs {
+		spec := &ScheduleSpec{Cron: "0 14 * * 1-5", StartingDeadline: tc.startingDeadline}
+		last, _ := time.Parse(time.RFC3339, tc.last)
+		now, _ := time.Parse(time.RFC3339, tc.now)
+
+		missed, ok := spec.MissedRun(last, now)
+		if len(tc.expectedMissed) == 0 {
+			g.Expect(ok).To(BeFalse(), tc.name)
+			continue
+		}
+		g.Expect(ok).To(BeTrue(), tc.name)
+		g.Expect(missed.UTC().Format(time.RFC3339)).To(Equal(tc.expectedMissed), tc.name)
+	}
+}
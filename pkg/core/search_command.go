@@ -18,13 +18,13 @@ import (
 )
 
 type SearchCommand struct {
-	Asc    bool
-	All    bool
-	Status string
-	Kind   string
-	Limit  uint32
-	Offset uint32
-	UID    string
+	Asc    bool   `form:"asc"`
+	All    bool   `form:"-"`
+	Status string `form:"status"`
+	Kind   string `form:"kind"`
+	Limit  uint32 `form:"limit"`
+	Offset uint32 `form:"offset"`
+	UID    string `form:"-"`
 }
 
 func (s *SearchCommand) Validate() error {
@@ -34,7 +34,7 @@ func (s *SearchCommand) Validate() error {
 
 	if len(s.Kind) > 0 {
 		switch s.Kind {
-		case NetworkAttack, ProcessAttack:
+		case NetworkAttack, ProcessAttack, StressAttack:
 			break
 		default:
 			return errors.Errorf("type %s not supported", s.Kind)
@@ -0,0 +1,128 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/json"
+
+	"github.com/pingcap/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ApplyCreated means the experiment of the spec is created
+	ApplyCreated = "created"
+	// ApplyConfigured means the spec is changed, the old experiment is recovered and the new one is created
+	ApplyConfigured = "configured"
+	// ApplyUnchanged means the experiment of the spec is running already
+	ApplyUnchanged = "unchanged"
+	// ApplyDeleted means the experiment of the spec is recovered
+	ApplyDeleted = "deleted"
+)
+
+// ExperimentSpec is the declarative format of an experiment, such as:
+//
+//	name: delay-web
+//	kind: network
+//	spec:
+//	  action: delay
+//	  device: eth0
+//	  latency: 10ms
+//
+// The spec is the command of the kind, its fields are matched with the fields of the command case-insensitively.
+type ExperimentSpec struct {
+	// Name identifies the experiment, applying the spec with the same name again doesn't create another experiment.
+	Name string          `json:"name"`
+	Kind string          `json:"kind"`
+	Spec json.RawMessage `json:"spec"`
+}
+
+// AttackCommand is the command of an attack
+type AttackCommand interface {
+	Validate() error
+}
+
+// ParseExperimentSpec parses the experiment spec in YAML or JSON
+func ParseExperimentSpec(data []byte) (*ExperimentSpec, error) {
+	spec := &ExperimentSpec{}
+	if err := yaml.Unmarshal(data, spec); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return spec, nil
+}
+
+func (s *ExperimentSpec) Validate() error {
+	if len(s.Name) == 0 {
+		return errors.New("name is required")
+	}
+
+	if len(s.Spec) == 0 {
+		return errors.New("spec is required")
+	}
+
+	cmd, err := s.Command()
+	if err != nil {
+		return err
+	}
+
+	return cmd.Validate()
+}
+
+// Command decodes the spec into the command of the kind, the fields unknown by the command are rejected.
+func (s *ExperimentSpec) Command() (AttackCommand, error) {
+	var cmd AttackCommand
+	switch s.Kind {
+	case ProcessAttack:
+		cmd = &ProcessCommand{}
+	case NetworkAttack:
+		cmd = &NetworkCommand{}
+	case StressAttack:
+		cmd = &StressCommand{}
+	default:
+		return nil, errors.Errorf("kind %s not supported", s.Kind)
+	}
+
+	// the scalars such as `percent: 50` are converted into the type of the field
+	if err := yaml.UnmarshalStrict(s.Spec, cmd); err != nil {
+		return nil, errors.Errorf("invalid spec of %s: %s", s.Kind, err)
+	}
+
+	if network, ok := cmd.(*NetworkCommand); ok {
+		network.SetDefault()
+	}
+
+	return cmd, nil
+}
+
+// String returns the spec in JSON, which is stored on the experiment and used to check whether the spec is changed.
+// The keys of the spec are sorted, so that the same spec is always encoded in the same way.
+func (s *ExperimentSpec) String() string {
+	normalized := *s
+
+	var spec interface{}
+	if err := json.Unmarshal(s.Spec, &spec); err == nil {
+		normalized.Spec, _ = json.Marshal(spec)
+	}
+
+	data, _ := json.Marshal(normalized)
+	return string(data)
+}
+
+// ApplyResult represents the operation performed by applying or deleting an experiment spec.
+type ApplyResult struct {
+	Name      string `json:"name"`
+	Uid       string `json:"uid"`
+	Operation string `json:"operation"`
+}
@@ -0,0 +1,102 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseExperimentSpec(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type TestCase struct {
+		name        string
+		data        string
+		expectedCmd AttackCommand
+		expectedErr string
+	}
+
+	tcs := []TestCase{
+		{
+			name: "network delay in yaml",
+			data: `
+name: delay-web
+kind: network
+spec:
+  action: delay
+  device: eth0
+  ipAddress: 172.16.4.4
+  latency: 10ms
+  percent: 50
+`,
+			expectedCmd: &NetworkCommand{
+				Action:      NetworkDelayAction,
+				Device:      "eth0",
+				IPAddress:   "172.16.4.4",
+				Latency:     "10ms",
+				Jitter:      "0ms",
+				Correlation: "0",
+				Percent:     "50",
+			},
+		},
+		{
+			name: "process stop in json",
+			data: `{"name": "stop-web", "kind": "process", "spec": {"action": "stop", "process": "nginx", "signal": 19}}`,
+			expectedCmd: &ProcessCommand{
+				Action:  ProcessStopAction,
+				Process: "nginx",
+				Signal:  19,
+			},
+		},
+		{
+			name:        "unknown field",
+			data:        `{"name": "stop-web", "kind": "process", "spec": {"action": "stop", "proces": "nginx"}}`,
+			expectedErr: "invalid spec of process",
+		},
+		{
+			name:        "unknown kind",
+			data:        `{"name": "stop-web", "kind": "kernel", "spec": {}}`,
+			expectedErr: "kind kernel not supported",
+		},
+		{
+			name:        "name is required",
+			data:        `{"kind": "process", "spec": {"action": "stop", "process": "nginx"}}`,
+			expectedErr: "name is required",
+		},
+	}
+
+	for _, tc := range tcs {
+		spec, err := ParseExperimentSpec([]byte(tc.data))
+		g.Expect(err).ShouldNot(HaveOccurred(), tc.name)
+
+		err = spec.Validate()
+		if len(tc.expectedErr) > 0 {
+			g.Expect(err).Should(HaveOccurred(), tc.name)
+			g.Expect(err.Error()).To(ContainSubstring(tc.expectedErr), tc.name)
+			continue
+		}
+		g.Expect(err).ShouldNot(HaveOccurred(), tc.name)
+
+		cmd, err := spec.Command()
+		g.Expect(err).ShouldNot(HaveOccurred(), tc.name)
+		g.Expect(cmd).To(Equal(tc.expectedCmd), tc.name)
+	}
+
+	// the same spec in yaml and json is stored in the same way
+	yamlSpec, _ := ParseExperimentSpec([]byte("name: stop-web\nkind: process\nspec:\n  signal: 19\n  process: nginx\n"))
+	jsonSpec, _ := ParseExperimentSpec([]byte(`{"kind": "process", "name": "stop-web", "spec": {"process": "nginx", "signal": 19}}`))
+	g.Expect(yamlSpec.String()).To(Equal(jsonSpec.String()))
+}
@@ -15,16 +15,41 @@ package core
 
 import (
 	"encoding/json"
-	"time"
+	"path/filepath"
+	"regexp"
 
 	"github.com/pingcap/errors"
+
+	"github.com/chaos-mesh/chaosd/pkg/utils"
 )
 
 const (
 	StressCPUAction = "cpu"
 	StressMemAction = "mem"
+	StressIOAction  = "io"
+)
+
+const (
+	// StressIOWrite writes the files sequentially by the hdd stressor
+	StressIOWrite = "write"
+	// StressIOReadWrite writes and then reads the files sequentially by the hdd stressor
+	StressIOReadWrite = "readwrite"
+	// StressIOMix mixes the sequential, random and memory mapped reads and writes by the iomix stressor
+	StressIOMix = "mix"
+)
+
+const (
+	// StressEngineStressng runs the stressors by stress-ng
+	StressEngineStressng = "stress-ng"
+	// StressEngineBuiltin runs the builtin stressors of chaosd
+	StressEngineBuiltin = "builtin"
+	// StressEngineAuto runs the stressors by stress-ng if it is installed, otherwise the builtin stressors
+	StressEngineAuto = "auto"
 )
 
+// ioSizePattern matches the size of stress-ng, such as 1024, 512m, 1g or 10%
+var ioSizePattern = regexp.MustCompile(`^[0-9]+[bBkKmMgG%]?$`)
+
 type StressCommand struct {
 	Action string
 
@@ -34,8 +59,43 @@ type StressCommand struct {
 
 	Options []string
 
-	Duration time.Duration
+	Duration string
+
+	// Path is the directory where the io stressors write the temporary files
+	Path string
+	// Device is the block device where the io stressors write the temporary files,
+	// the files are written in the mount point of the device.
+	Device string
+	// Size is the bytes written by each io stressor, such as 1g or 10% of the free space,
+	// or the memory consumed by all the memory stressors, such as 6g or 80% of the available memory.
+	Size string
+	// Mode is the read and write mix of the io stressors, it is one of write, readwrite and mix, default is write
+	Mode string
+	// TempDir is the directory of the temporary files created by the io stressors, it is removed when recovered
+	TempDir string
+
+	// Hang makes the memory stressors hold the memory after it is allocated, instead of unmapping and mapping it again
+	Hang bool
+	// Keep makes the memory stressors keep rewriting the memory, instead of unmapping and mapping it again
+	Keep bool
+	// PeakRSS is the max resident memory of the stressors in bytes, which is observed by chaosd server
+	PeakRSS uint64
+
+	// CPUs are the CPUs which the cpu stressors are pinned to, such as 2-3 or 0,2,4
+	CPUs string
+	// Cgroup is the cgroup directory which the cpu stressors are moved into, both cgroup v1 and v2 are supported,
+	// such as /sys/fs/cgroup/cpu/app in cgroup v1 or /sys/fs/cgroup/app in cgroup v2.
+	Cgroup string
 
+	// Engine is the engine which runs the stressors, it is one of stress-ng, builtin and auto, default is auto.
+	// The engine is resolved when the attack is created, so it is stress-ng or builtin in the experiment.
+	Engine string
+
+	// Container is the container which the stressors run in, such as docker://<id> or containerd://<id>,
+	// the stressors join the namespaces and cgroups of the container. Empty means the host.
+	Container string
+
+	// StressngPid is the pid of stress-ng, or the chaosd process running the builtin stressors
 	StressngPid int32
 }
 
@@ -44,6 +104,112 @@ func (s *StressCommand) Validate() error {
 		return errors.New("action not provided")
 	}
 
+	switch s.Action {
+	case StressCPUAction:
+		if err := s.validateCPU(); err != nil {
+			return errors.WithStack(err)
+		}
+	case StressMemAction:
+		if err := s.validateMem(); err != nil {
+			return errors.WithStack(err)
+		}
+	case StressIOAction:
+		if err := s.validateIO(); err != nil {
+			return errors.WithStack(err)
+		}
+	default:
+		return errors.Errorf("stress action %s not supported", s.Action)
+	}
+
+	if s.Action != StressCPUAction && (len(s.CPUs) > 0 || len(s.Cgroup) > 0) {
+		return errors.New("cpus and cgroup are only supported by the cpu stress")
+	}
+
+	switch s.Engine {
+	case "", StressEngineAuto, StressEngineStressng:
+	case StressEngineBuiltin:
+		if len(s.Options) > 0 {
+			return errors.New("the options of stress-ng can not be used by the builtin engine")
+		}
+	default:
+		return errors.Errorf("stress engine %s not supported, it should be %s, %s or %s",
+			s.Engine, StressEngineStressng, StressEngineBuiltin, StressEngineAuto)
+	}
+
+	if !utils.CheckDuration(s.Duration) {
+		return errors.Errorf("duration %s not valid", s.Duration)
+	}
+
+	if err := validContainer(s.Container); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+func (s *StressCommand) validateCPU() error {
+	if len(s.CPUs) > 0 {
+		if _, err := utils.ParseCPUList(s.CPUs); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if len(s.Cgroup) > 0 {
+		if !filepath.IsAbs(s.Cgroup) {
+			return errors.Errorf("cgroup %s should be an absolute path", s.Cgroup)
+		}
+
+		if len(s.Container) > 0 {
+			return errors.New("cgroup can not be set with the container, the stressors join the cgroups of the container")
+		}
+	}
+
+	return nil
+}
+
+func (s *StressCommand) validateMem() error {
+	if len(s.Size) == 0 {
+		if s.Hang || s.Keep {
+			return errors.New("hang or keep requires the size of the memory")
+		}
+		return nil
+	}
+
+	if s.Workers <= 0 {
+		return errors.Errorf("workers %d should be positive", s.Workers)
+	}
+
+	if _, err := utils.ParseSize(s.Size, 0); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if s.Hang && s.Keep {
+		return errors.New("hang and keep can not be set at the same time")
+	}
+
+	return nil
+}
+
+func (s *StressCommand) validateIO() error {
+	if s.Workers <= 0 {
+		return errors.Errorf("workers %d should be positive", s.Workers)
+	}
+
+	if len(s.Path) > 0 && len(s.Device) > 0 {
+		return errors.New("path and device can not be set at the same time")
+	}
+
+	if len(s.Size) > 0 && !ioSizePattern.MatchString(s.Size) {
+		return errors.Errorf("size %s not valid, it should be bytes with the unit b, k, m, g or a percent, such as 1g", s.Size)
+	}
+
+	switch s.Mode {
+	case "", StressIOWrite, StressIOReadWrite, StressIOMix:
+	default:
+		return errors.Errorf("io mode %s not supported, it should be %s, %s or %s",
+			s.Mode, StressIOWrite, StressIOReadWrite, StressIOMix)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,63 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestStressValidate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type TestCase struct {
+		name        string
+		attack      StressCommand
+		expectedErr bool
+	}
+
+	tcs := []TestCase{
+		{name: "cpu", attack: StressCommand{Action: StressCPUAction, Workers: 1}},
+		{name: "cpu with cpus and cgroup", attack: StressCommand{Action: StressCPUAction, Workers: 2, CPUs: "2-3", Cgroup: "/sys/fs/cgroup/app"}},
+		{name: "invalid cpus", attack: StressCommand{Action: StressCPUAction, Workers: 1, CPUs: "3-2"}, expectedErr: true},
+		{name: "relative cgroup", attack: StressCommand{Action: StressCPUAction, Workers: 1, Cgroup: "app"}, expectedErr: true},
+		{name: "cgroup with container", attack: StressCommand{Action: StressCPUAction, Workers: 1, Cgroup: "/sys/fs/cgroup/app", Container: "docker://abc"}, expectedErr: true},
+		{name: "mem with cpus", attack: StressCommand{Action: StressMemAction, Workers: 1, CPUs: "1"}, expectedErr: true},
+		{name: "unknown action", attack: StressCommand{Action: "disk"}, expectedErr: true},
+		{name: "mem", attack: StressCommand{Action: StressMemAction, Workers: 1}},
+		{name: "mem with size", attack: StressCommand{Action: StressMemAction, Workers: 2, Size: "6g", Keep: true}},
+		{name: "mem with percent", attack: StressCommand{Action: StressMemAction, Workers: 1, Size: "80%", Hang: true}},
+		{name: "mem with invalid size", attack: StressCommand{Action: StressMemAction, Workers: 1, Size: "6t"}, expectedErr: true},
+		{name: "mem hang without size", attack: StressCommand{Action: StressMemAction, Workers: 1, Hang: true}, expectedErr: true},
+		{name: "mem hang and keep", attack: StressCommand{Action: StressMemAction, Workers: 1, Size: "1g", Hang: true, Keep: true}, expectedErr: true},
+		{name: "builtin engine", attack: StressCommand{Action: StressCPUAction, Workers: 1, Engine: StressEngineBuiltin}},
+		{name: "builtin engine with options", attack: StressCommand{Action: StressCPUAction, Workers: 1, Engine: StressEngineBuiltin, Options: []string{"--cpu-method", "fft"}}, expectedErr: true},
+		{name: "unknown engine", attack: StressCommand{Action: StressCPUAction, Workers: 1, Engine: "stress"}, expectedErr: true},
+		{name: "io", attack: StressCommand{Action: StressIOAction, Workers: 2, Size: "1g", Mode: StressIOMix, Path: "/data"}},
+		{name: "io with percent", attack: StressCommand{Action: StressIOAction, Workers: 1, Size: "10%"}},
+		{name: "io without workers", attack: StressCommand{Action: StressIOAction}, expectedErr: true},
+		{name: "invalid size", attack: StressCommand{Action: StressIOAction, Workers: 1, Size: "1t"}, expectedErr: true},
+		{name: "invalid mode", attack: StressCommand{Action: StressIOAction, Workers: 1, Mode: "read"}, expectedErr: true},
+		{name: "path and device", attack: StressCommand{Action: StressIOAction, Workers: 1, Path: "/data", Device: "/dev/sdb"}, expectedErr: true},
+	}
+
+	for _, tc := range tcs {
+		if tc.expectedErr {
+			g.Expect(tc.attack.Validate()).To(HaveOccurred(), tc.name)
+		} else {
+			g.Expect(tc.attack.Validate()).NotTo(HaveOccurred(), tc.name)
+		}
+	}
+}
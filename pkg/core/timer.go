@@ -0,0 +1,39 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TimerStore defines operations for working with the timers of experiments
+type TimerStore interface {
+	List(ctx context.Context) ([]*Timer, error)
+	ListExpired(ctx context.Context, now time.Time) ([]*Timer, error)
+	Set(ctx context.Context, timer *Timer) error
+	DeleteByExperiment(ctx context.Context, experiment string) error
+}
+
+// Timer represents the deadline of an experiment,
+// the experiment will be recovered automatically after the deadline.
+type Timer struct {
+	gorm.Model
+	// Experiment represents the experiment which the timer belong to.
+	Experiment string `gorm:"index:experiment" json:"experiment"`
+	// Deadline is the time when the experiment should be recovered.
+	Deadline time.Time `gorm:"index:deadline" json:"deadline"`
+}
@@ -0,0 +1,275 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	WorkflowPending   = "pending"
+	WorkflowRunning   = "running"
+	WorkflowSucceeded = "succeeded"
+	WorkflowFailed    = "failed"
+	WorkflowAborted   = "aborted"
+)
+
+const (
+	WorkflowStepExperiment = "experiment"
+	WorkflowStepSerial     = "serial"
+	WorkflowStepParallel   = "parallel"
+	WorkflowStepSuspend    = "suspend"
+)
+
+// WorkflowStore defines operations for working with workflows and the states of their steps
+type WorkflowStore interface {
+	List(ctx context.Context) ([]*Workflow, error)
+	ListByStatus(ctx context.Context, status string) ([]*Workflow, error)
+	FindByUid(ctx context.Context, uid string) (*Workflow, error)
+	Set(ctx context.Context, workflow *Workflow) error
+	Update(ctx context.Context, uid, status, msg string) error
+	ListNodes(ctx context.Context, workflow string) ([]*WorkflowNode, error)
+	SetNode(ctx context.Context, node *WorkflowNode) error
+}
+
+// Workflow represents a workflow instance, its steps are run by chaosd server.
+type Workflow struct {
+	ID        uint      `gorm:"primary_key" json:"id"`
+	Uid       string    `gorm:"index:workflow_uid" json:"uid"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Message   string    `json:"error"`
+	// Spec is the original spec of the workflow in JSON
+	Spec string `json:"spec"`
+}
+
+// WorkflowNode represents the state of a step of a workflow.
+type WorkflowNode struct {
+	ID       uint   `gorm:"primary_key" json:"id"`
+	Workflow string `gorm:"index:workflow_node_workflow" json:"workflow"`
+	// Path is the names of the step and its ancestors joined by "/", such as stress-and-loss/stress
+	Path   string `json:"path"`
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	// Experiment is the uid of the experiment created by the step
+	Experiment string     `json:"experiment,omitempty"`
+	Message    string     `json:"error,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// WorkflowStatus represents a workflow and the states of its steps
+type WorkflowStatus struct {
+	*Workflow
+	Nodes []*WorkflowNode `json:"nodes"`
+}
+
+// WorkflowSpec is the declarative format of a workflow, the steps are run in serial:
+//
+//	name: game-day
+//	steps:
+//	- name: delay
+//	  kind: network
+//	  spec:
+//	    action: delay
+//	    device: eth0
+//	    latency: 200ms
+//	  duration: 5m
+//	- name: wait
+//	  suspend: 2m
+//	- name: stress-and-loss
+//	  parallel:
+//	  - name: stress
+//	    kind: stress
+//	    spec:
+//	      action: cpu
+//	      workers: 1
+//	  - name: loss
+//	    kind: network
+//	    spec:
+//	      action: loss
+//	      device: eth0
+//	      percent: 10
+type WorkflowSpec struct {
+	Name  string          `json:"name"`
+	Steps []*WorkflowStep `json:"steps"`
+}
+
+// WorkflowStep is an experiment, a group of serial or parallel steps, or a suspend,
+// only one of them can be specified in a step.
+type WorkflowStep struct {
+	Name string `json:"name"`
+
+	// Kind and Spec describe the experiment created by the step, the same as ExperimentSpec.
+	Kind string          `json:"kind,omitempty"`
+	Spec json.RawMessage `json:"spec,omitempty"`
+	// Duration is how long the experiment lasts before it is recovered by the workflow,
+	// empty means the experiment lasts until the workflow ends.
+	Duration string `json:"duration,omitempty"`
+
+	Serial   []*WorkflowStep `json:"serial,omitempty"`
+	Parallel []*WorkflowStep `json:"parallel,omitempty"`
+	// Suspend is how long the workflow sleeps
+	Suspend string `json:"suspend,omitempty"`
+}
+
+// ParseWorkflowSpec parses the workflow spec in YAML or JSON
+func ParseWorkflowSpec(data []byte) (*WorkflowSpec, error) {
+	spec := &WorkflowSpec{}
+	if err := yaml.Unmarshal(data, spec); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return spec, nil
+}
+
+func (w *WorkflowSpec) Validate() error {
+	if len(w.Name) == 0 {
+		return errors.New("name is required")
+	}
+
+	return validateSteps(w.Name, w.Steps)
+}
+
+func (w *WorkflowSpec) String() string {
+	data, _ := json.Marshal(w)
+	return string(data)
+}
+
+func validateSteps(path string, steps []*WorkflowStep) error {
+	if len(steps) == 0 {
+		return errors.Errorf("steps of %s are required", path)
+	}
+
+	names := make(map[string]struct{})
+	for _, step := range steps {
+		if len(step.Name) == 0 {
+			return errors.Errorf("name of the steps of %s is required", path)
+		}
+
+		if strings.Contains(step.Name, "/") {
+			return errors.Errorf("name %s of step is invalid, / is not allowed", step.Name)
+		}
+
+		if _, ok := names[step.Name]; ok {
+			return errors.Errorf("step %s/%s is duplicated", path, step.Name)
+		}
+		names[step.Name] = struct{}{}
+
+		if err := step.validate(path + "/" + step.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *WorkflowStep) validate(path string) error {
+	types := 0
+	for _, specified := range []bool{len(s.Kind) > 0, s.Serial != nil, s.Parallel != nil, len(s.Suspend) > 0} {
+		if specified {
+			types++
+		}
+	}
+	if types != 1 {
+		return errors.Errorf("step %s should be one of experiment, serial, parallel and suspend", path)
+	}
+
+	if len(s.Duration) > 0 && s.Type() != WorkflowStepExperiment {
+		return errors.Errorf("duration of step %s is only supported by experiment", path)
+	}
+
+	switch s.Type() {
+	case WorkflowStepExperiment:
+		cmd, err := s.Command()
+		if err != nil {
+			return errors.Errorf("step %s: %s", path, err)
+		}
+
+		if err := cmd.Validate(); err != nil {
+			return errors.Errorf("step %s: %s", path, err)
+		}
+
+		if len(s.Duration) > 0 {
+			if _, err := s.DurationTime(); err != nil {
+				return errors.Errorf("step %s: %s", path, err)
+			}
+		}
+	case WorkflowStepSerial:
+		return validateSteps(path, s.Serial)
+	case WorkflowStepParallel:
+		return validateSteps(path, s.Parallel)
+	case WorkflowStepSuspend:
+		if _, err := s.DurationTime(); err != nil {
+			return errors.Errorf("step %s: %s", path, err)
+		}
+	}
+
+	return nil
+}
+
+// Type returns the type of the step, such as experiment
+func (s *WorkflowStep) Type() string {
+	switch {
+	case len(s.Kind) > 0:
+		return WorkflowStepExperiment
+	case s.Parallel != nil:
+		return WorkflowStepParallel
+	case len(s.Suspend) > 0:
+		return WorkflowStepSuspend
+	default:
+		return WorkflowStepSerial
+	}
+}
+
+// Children returns the steps of the serial or parallel step
+func (s *WorkflowStep) Children() []*WorkflowStep {
+	if s.Type() == WorkflowStepParallel {
+		return s.Parallel
+	}
+
+	return s.Serial
+}
+
+// Command decodes the spec of the experiment step into the command of the kind
+func (s *WorkflowStep) Command() (AttackCommand, error) {
+	return (&ExperimentSpec{Name: s.Name, Kind: s.Kind, Spec: s.Spec}).Command()
+}
+
+// DurationTime returns the duration of the experiment step or the suspend step
+func (s *WorkflowStep) DurationTime() (time.Duration, error) {
+	duration := s.Duration
+	if s.Type() == WorkflowStepSuspend {
+		duration = s.Suspend
+	}
+
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	if d <= 0 {
+		return 0, errors.Errorf("duration %s should be positive", duration)
+	}
+
+	return d, nil
+}
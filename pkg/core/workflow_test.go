@@ -0,0 +1,93 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestValidateWorkflowSpec(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type TestCase struct {
+		name        string
+		data        string
+		expectedErr string
+	}
+
+	tcs := []TestCase{
+		{
+			name: "serial and parallel steps",
+			data: `
+name: game-day
+steps:
+- name: stop
+  kind: process
+  spec: {action: stop, process: nginx, signal: 19}
+  duration: 5m
+- name: wait
+  suspend: 2m
+- name: group
+  parallel:
+  - name: stop
+    kind: process
+    spec: {action: stop, process: nginx, signal: 19}
+  - name: wait
+    serial:
+    - name: wait
+      suspend: 1m
+`,
+		},
+		{
+			name:        "duplicated steps",
+			data:        "name: game-day\nsteps:\n- {name: wait, suspend: 1m}\n- {name: wait, suspend: 2m}\n",
+			expectedErr: "step game-day/wait is duplicated",
+		},
+		{
+			name:        "more than one type",
+			data:        "name: game-day\nsteps:\n- {name: wait, suspend: 1m, serial: [{name: wait, suspend: 1m}]}\n",
+			expectedErr: "should be one of experiment, serial, parallel and suspend",
+		},
+		{
+			name:        "duration of suspend",
+			data:        "name: game-day\nsteps:\n- {name: wait, suspend: 1m, duration: 1m}\n",
+			expectedErr: "duration of step game-day/wait is only supported by experiment",
+		},
+		{
+			name:        "invalid experiment",
+			data:        "name: game-day\nsteps:\n- {name: group, parallel: [{name: stop, kind: process, spec: {action: stop}}]}\n",
+			expectedErr: "step game-day/group/stop",
+		},
+		{
+			name:        "empty group",
+			data:        "name: game-day\nsteps:\n- {name: group, parallel: []}\n",
+			expectedErr: "steps of game-day/group are required",
+		},
+	}
+
+	for _, tc := range tcs {
+		spec, err := ParseWorkflowSpec([]byte(tc.data))
+		g.Expect(err).ShouldNot(HaveOccurred(), tc.name)
+
+		err = spec.Validate()
+		if len(tc.expectedErr) == 0 {
+			g.Expect(err).ShouldNot(HaveOccurred(), tc.name)
+			continue
+		}
+		g.Expect(err).Should(HaveOccurred(), tc.name)
+		g.Expect(err.Error()).To(ContainSubstring(tc.expectedErr), tc.name)
+	}
+}
@@ -17,8 +17,13 @@ import (
 	"context"
 
 	"github.com/chaos-mesh/chaos-mesh/pkg/chaosdaemon"
+
+	"github.com/chaos-mesh/chaosd/pkg/config"
+	"github.com/chaos-mesh/chaosd/pkg/container"
 )
 
+// NewNodeCRClient creates the container runtime client used by chaos daemon,
+// the pid is returned for the empty container ID, which means the attack is applied on the host.
 func NewNodeCRClient(pid int) chaosdaemon.ContainerRuntimeInfoClient {
 	return &NodeCRClient{
 		Pid: uint32(pid),
@@ -29,14 +34,51 @@ type NodeCRClient struct {
 	Pid uint32
 }
 
-func (n *NodeCRClient) GetPidFromContainerID(_ context.Context, _ string) (uint32, error) {
-	return n.Pid, nil
+func (n *NodeCRClient) GetPidFromContainerID(ctx context.Context, containerID string) (uint32, error) {
+	if len(containerID) == 0 {
+		return n.Pid, nil
+	}
+
+	cli, err := newCRIClient(containerID)
+	if err != nil {
+		return 0, err
+	}
+
+	return cli.GetPidFromContainerID(ctx, containerID)
 }
 
-func (n *NodeCRClient) ContainerKillByContainerID(_ context.Context, _ string) error {
-	return nil
+func (n *NodeCRClient) ContainerKillByContainerID(ctx context.Context, containerID string) error {
+	if len(containerID) == 0 {
+		return nil
+	}
+
+	cli, err := newCRIClient(containerID)
+	if err != nil {
+		return err
+	}
+
+	return cli.ContainerKillByContainerID(ctx, containerID)
 }
 
-func (n *NodeCRClient) FormatContainerID(_ context.Context, _ string) (string, error) {
-	return "", nil
+func (n *NodeCRClient) FormatContainerID(ctx context.Context, containerID string) (string, error) {
+	if len(containerID) == 0 {
+		return "", nil
+	}
+
+	cli, err := newCRIClient(containerID)
+	if err != nil {
+		return "", err
+	}
+
+	return cli.FormatContainerID(ctx, containerID)
+}
+
+// newCRIClient creates the client of the container runtime which the container ID belongs to
+func newCRIClient(containerID string) (container.CRIClient, error) {
+	runtime, err := container.Runtime(containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return container.NewCRIClient(&config.Config{Runtime: runtime})
 }
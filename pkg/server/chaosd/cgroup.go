@@ -0,0 +1,85 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/errors"
+)
+
+// cgroupRoot is the mount point of the cgroup filesystems
+const cgroupRoot = "/sys/fs/cgroup"
+
+// joinCgroupsOf moves the process into the cgroups of the target process, both cgroup v1 and v2 are supported.
+func joinCgroupsOf(pid int, target int) error {
+	data, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cgroup", target))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		// the format of line is hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+
+		dir := cgroupDir(parts[1], parts[2])
+		if _, err := os.Stat(dir); err != nil {
+			// the hierarchy is not mounted
+			continue
+		}
+
+		if err := addToCgroup(dir, pid); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// cgroupDir returns the directory of the cgroup in the hierarchy of the controllers,
+// the empty controllers means the unified hierarchy of cgroup v2.
+func cgroupDir(controllers string, path string) string {
+	if len(controllers) == 0 {
+		// the unified hierarchy is mounted on cgroupRoot/unified in the hybrid mode
+		if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err != nil {
+			return filepath.Join(cgroupRoot, "unified", path)
+		}
+
+		return filepath.Join(cgroupRoot, path)
+	}
+
+	// the named hierarchy such as name=systemd is mounted on cgroupRoot/systemd
+	return filepath.Join(cgroupRoot, strings.TrimPrefix(controllers, "name="), path)
+}
+
+// joinCgroup moves the process into the cgroup directory, which is in a hierarchy of cgroup v1 or the unified hierarchy of v2
+func joinCgroup(pid int, dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, "cgroup.procs")); err != nil {
+		return errors.Errorf("%s is not a cgroup directory", dir)
+	}
+
+	return addToCgroup(dir, pid)
+}
+
+func addToCgroup(dir string, pid int) error {
+	return errors.WithStack(ioutil.WriteFile(filepath.Join(dir, "cgroup.procs"), []byte(strconv.Itoa(pid)), 0644))
+}
@@ -0,0 +1,102 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package chaosdtest provides the chaosd server and the processes to attack for the tests of chaosd server.
+package chaosdtest
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/chaos-mesh/chaos-mesh/pkg/chaosdaemon"
+	"github.com/shirou/gopsutil/process"
+
+	"github.com/chaos-mesh/chaosd/pkg/config"
+	"github.com/chaos-mesh/chaosd/pkg/crclient"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd"
+	"github.com/chaos-mesh/chaosd/pkg/store/dbstore"
+	"github.com/chaos-mesh/chaosd/pkg/store/experiment"
+	"github.com/chaos-mesh/chaosd/pkg/store/network"
+	procstore "github.com/chaos-mesh/chaosd/pkg/store/process"
+	"github.com/chaos-mesh/chaosd/pkg/store/timer"
+)
+
+// NewDB returns a DB in a temporary directory, which is removed after the test
+func NewDB(t *testing.T) *dbstore.DB {
+	dir, err := ioutil.TempDir("", "chaosd-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	db, err := dbstore.NewDBStoreWithFile(filepath.Join(dir, "chaosd.dat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+// NewServer returns the chaosd server storing the experiments in the DB
+func NewServer(db *dbstore.DB) *chaosd.Server {
+	crClient := crclient.NewNodeCRClient(os.Getpid())
+
+	return chaosd.NewServer(
+		&config.Config{Platform: config.LocalPlatform, Runtime: "docker"},
+		experiment.NewStore(db),
+		network.NewIPSetRuleStore(db),
+		network.NewIptablesRuleStore(db),
+		network.NewTCRuleStore(db),
+		network.NewIFBRuleStore(db),
+		timer.NewStore(db),
+		procstore.NewEventStore(db),
+		procstore.NewSnapshotStore(db),
+		chaosdaemon.NewDaemonServerWithCRClient(crClient),
+		crClient,
+	)
+}
+
+// StartProcess starts a process to be attacked, it is killed after the test
+func StartProcess(t *testing.T, name string, args ...string) *exec.Cmd {
+	cmd := exec.Command(name, args...)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	return cmd
+}
+
+// StartSleepProcess starts a sleep process to be attacked, it is killed after the test
+func StartSleepProcess(t *testing.T) int {
+	return StartProcess(t, "sleep", "60").Process.Pid
+}
+
+// ProcessStatus returns the status of the process, such as T for the stopped process,
+// or empty if the process does not exist.
+func ProcessStatus(pid int) string {
+	proc, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return ""
+	}
+
+	status, _ := proc.Status()
+	return status
+}
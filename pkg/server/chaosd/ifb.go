@@ -0,0 +1,211 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/errors"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+// ingressHandle is the handle of the ingress qdisc
+const ingressHandle = "ffff:"
+
+var (
+	// the errors about the existing device, qdisc or filter
+	existErrs = []string{"File exists", "Exclusivity flag on"}
+	// the errors about the nonexistent device, qdisc or filter, the old versions of tc only
+	// report "No such file or directory" from the kernel
+	notExistErrs = []string{"Cannot find device", "No such file or directory",
+		"Cannot find specified qdisc", "Parent Qdisc doesn't exist", "Cannot find specified filter chain",
+		"Filter with specified priority/protocol not found"}
+)
+
+// applyIngressTC redirects the ingress traffic of the device to a new IFB device,
+// and applies the traffic control on the IFB device.
+// Every experiment has its own IFB device, and the traffic is filtered when it is redirected,
+// so that the experiments with different filters on the same device don't affect each other.
+func (s *Server) applyIngressTC(attack *core.NetworkCommand, ipset string, ipset6 string, uid string) error {
+	ns, err := s.netNSOf(attack.Container)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	rules, err := s.ifbRule.FindByDevice(context.Background(), attack.Container, attack.Device)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var priority uint32 = 1
+	for _, rule := range rules {
+		if rule.Priority >= priority {
+			priority = rule.Priority + 1
+		}
+	}
+
+	name := fmt.Sprintf("ifb-%s", uid[:8])
+	for _, filter := range attack.IngressFilters(ipset, ipset6) {
+		rule := &core.IFBRule{
+			Name:       name,
+			Device:     attack.Device,
+			Priority:   priority,
+			Protocol:   filter.Protocol,
+			Match:      filter.Match,
+			Container:  attack.Container,
+			Experiment: uid,
+		}
+		priority++
+
+		// the rule is recorded before setting up the IFB device,
+		// so that the partially set up IFB device can be removed when recovering.
+		if err := s.ifbRule.Set(context.Background(), rule); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := ns.setupIFB(rule); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	newTCs, params, err := attack.ToIngressTCs()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(s.appendTCs(attack.Container, name, newTCs, params, uid))
+}
+
+// recoverIngressTC removes the IFB devices and redirect filters of the experiment,
+// the ingress qdisc is removed if no other experiment uses it.
+func (s *Server) recoverIngressTC(uid string, container string) error {
+	ns, err := s.netNSOf(container)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	rules, err := s.ifbRule.FindByExperiment(context.Background(), uid)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, rule := range rules {
+		if err := ns.teardownIFB(rule); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if err := s.ifbRule.DeleteByExperiment(context.Background(), uid); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, rule := range rules {
+		others, err := s.ifbRule.FindByDevice(context.Background(), rule.Container, rule.Device)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if len(others) > 0 {
+			continue
+		}
+
+		if err := ns.execIgnoreErrs(notExistErrs, "tc", "qdisc", "del", "dev", rule.Device, "handle", ingressHandle, "ingress"); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// setupIFB creates the IFB device and redirects the ingress traffic of the device to it, it is idempotent.
+func (ns netNS) setupIFB(rule *core.IFBRule) error {
+	if err := ns.execIgnoreErrs(existErrs, "ip", "link", "add", rule.Name, "type", "ifb"); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := ns.execIgnoreErrs(nil, "ip", "link", "set", "dev", rule.Name, "up"); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := ns.execIgnoreErrs(existErrs, "tc", "qdisc", "add", "dev", rule.Device, "handle", ingressHandle, "ingress"); err != nil {
+		return errors.WithStack(err)
+	}
+
+	// remove the filter added before, the filter with the same priority will not be replaced
+	if err := ns.deleteRedirectFilter(rule); err != nil {
+		return errors.WithStack(err)
+	}
+
+	args := []string{"filter", "add", "dev", rule.Device, "parent", ingressHandle,
+		"protocol", rule.Protocol, "prio", strconv.Itoa(int(rule.Priority))}
+	if len(rule.Match) > 0 {
+		args = append(args, "basic", "match", rule.Match)
+	} else {
+		args = append(args, "u32", "match", "u32", "0", "0")
+	}
+	args = append(args, "action", "mirred", "egress", "redirect", "dev", rule.Name)
+
+	return errors.WithStack(ns.execIgnoreErrs(nil, "tc", args...))
+}
+
+// teardownIFB removes the redirect filter and the IFB device, the qdiscs on the IFB device are removed with it.
+func (ns netNS) teardownIFB(rule *core.IFBRule) error {
+	if err := ns.deleteRedirectFilter(rule); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(ns.execIgnoreErrs(notExistErrs, "ip", "link", "del", rule.Name))
+}
+
+func (ns netNS) deleteRedirectFilter(rule *core.IFBRule) error {
+	return ns.execIgnoreErrs(notExistErrs, "tc", "filter", "del", "dev", rule.Device,
+		"parent", ingressHandle, "prio", strconv.Itoa(int(rule.Priority)))
+}
+
+// ifbRedirected checks whether the IFB device exists and the ingress traffic is redirected to it
+func (ns netNS) ifbRedirected(rule *core.IFBRule) bool {
+	if err := ns.command("ip", "link", "show", rule.Name).Run(); err != nil {
+		return false
+	}
+
+	cmd := ns.command("tc", "filter", "show", "dev", rule.Device, "parent", ingressHandle)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return false
+	}
+
+	return strings.Contains(string(out), rule.Name)
+}
+
+// execIgnoreErrs executes the command, the error is ignored if the output contains one of ignoredErrs.
+func (ns netNS) execIgnoreErrs(ignoredErrs []string, name string, args ...string) error {
+	cmd := ns.command(name, args...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		output := string(out)
+		for _, e := range ignoredErrs {
+			if strings.Contains(output, e) {
+				return nil
+			}
+		}
+
+		return errors.Errorf("%s %s failed: %s, %s", name, strings.Join(args, " "), output, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"fmt"
+
+	"github.com/pingcap/errors"
+
+	"github.com/chaos-mesh/chaos-mesh/pkg/chaosdaemon/pb"
+)
+
+const (
+	ipsetExistErr        = "set with the same name already exists"
+	ipsetNewNameExistErr = "a set with the new name already exists"
+	ipsetIPExistErr      = "it's already added"
+	ipsetNotExistErr     = "The set with the given name does not exist"
+)
+
+// flushIPv6Set creates the ipset of inet6 family in the same way as chaos daemon creates the ipset,
+// the addresses are added to a temporary ipset which is swapped with the existing ipset then.
+func (ns netNS) flushIPv6Set(set *pb.IPSet) error {
+	tmpName := fmt.Sprintf("%sold", set.Name)
+
+	if err := ns.execIgnoreErrs([]string{ipsetExistErr}, "ipset", "create", tmpName, "hash:net", "family", "inet6"); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := ns.execIgnoreErrs(nil, "ipset", "flush", tmpName); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, cidr := range set.Cidrs {
+		if err := ns.execIgnoreErrs([]string{ipsetIPExistErr}, "ipset", "add", tmpName, cidr); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if err := ns.execIgnoreErrs([]string{ipsetNewNameExistErr}, "ipset", "rename", tmpName, set.Name); err != nil {
+		return errors.WithStack(err)
+	}
+
+	// the temporary ipset is renamed successfully if it doesn't exist
+	if !ns.ipsetExists(tmpName) {
+		return nil
+	}
+
+	// the ipset used by iptables rules can not be deleted, so swap it with the temporary ipset
+	if err := ns.execIgnoreErrs(nil, "ipset", "swap", tmpName, set.Name); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(ns.execIgnoreErrs([]string{ipsetNotExistErr}, "ipset", "destroy", tmpName))
+}
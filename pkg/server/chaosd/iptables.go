@@ -0,0 +1,302 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/errors"
+
+	"github.com/chaos-mesh/chaos-mesh/pkg/chaosdaemon/pb"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+const (
+	iptablesCmd  = "iptables"
+	ip6tablesCmd = "ip6tables"
+
+	iptablesChainNotExistErr = "No chain/target/match by that name"
+	iptablesRuleNotExistErr  = "does a matching rule exist in that chain"
+	iptablesChainExistErr    = "Chain already exists"
+
+	// tcChainPrefix is the prefix of the chains created by chaos daemon to classify the traffic for tc filters
+	tcChainPrefix = "TC-TABLES-"
+)
+
+func iptablesCmdOfFamily(family string) string {
+	if family == core.IPFamilyIPv6 {
+		return ip6tablesCmd
+	}
+
+	return iptablesCmd
+}
+
+// deleteIptablesChain removes the chain from CHAOS-INPUT or CHAOS-OUTPUT chain, and then deletes it.
+func (ns netNS) deleteIptablesChain(cmd string, chain *pb.Chain) error {
+	parent := "CHAOS-" + chain.Direction.String()
+
+	if err := ns.xtables(cmd, "-D", parent, "-j", chain.Name); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := ns.xtables(cmd, "-F", chain.Name); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(ns.xtables(cmd, "-X", chain.Name))
+}
+
+// xtables executes the iptables or ip6tables command, the error about nonexistent chain or rule is ignored.
+func (ns netNS) xtables(cmd string, args ...string) error {
+	out, err := ns.command(cmd, append([]string{"-w"}, args...)...).CombinedOutput()
+	if err != nil {
+		output := string(out)
+		if strings.Contains(output, iptablesChainNotExistErr) || strings.Contains(output, iptablesRuleNotExistErr) {
+			return nil
+		}
+
+		return errors.Errorf("%s %s failed: %s, %s", cmd, strings.Join(args, " "), output, err)
+	}
+
+	return nil
+}
+
+// ip6tablesAvailable checks whether ip6tables can be used in the network namespace
+func (ns netNS) ip6tablesAvailable() bool {
+	return ns.command(ip6tablesCmd, "-w", "-S", "INPUT").Run() == nil
+}
+
+// needApplyIPv6Iptables returns true if the chains should also be set by ip6tables.
+// The chains without ipset are set by ip6tables only if the host supports it or ipv6 family is chosen explicitly.
+func needApplyIPv6Iptables(ns netNS, attack *core.NetworkCommand, ipset6 string) bool {
+	if len(ipset6) > 0 {
+		return true
+	}
+
+	if attack.NeedApplyIPSet() || attack.IPFamily == core.IPFamilyIPv4 {
+		return false
+	}
+
+	return attack.IPFamily == core.IPFamilyIPv6 || ns.ip6tablesAvailable()
+}
+
+// setIp6tablesChains sets the chains by ip6tables in the same way as chaos daemon sets the chains by iptables.
+func (ns netNS) setIp6tablesChains(chains []*pb.Chain) error {
+	if err := ns.initIp6tablesEnv(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, chain := range chains {
+		if err := ns.newIp6tablesChain(chain.Name); err != nil {
+			return errors.WithStack(err)
+		}
+
+		for _, rule := range ip6tablesRules(chain) {
+			if err := ns.xtables(ip6tablesCmd, rule...); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		if err := ns.ensureIp6tablesRule("CHAOS-"+chain.Direction.String(), "-j", chain.Name); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// initIp6tablesEnv creates the CHAOS-INPUT and CHAOS-OUTPUT chains, the chains created by chaosd are linked to them.
+func (ns netNS) initIp6tablesEnv() error {
+	for _, direction := range []string{"INPUT", "OUTPUT"} {
+		chain := "CHAOS-" + direction
+		if err := ns.execIgnoreErrs([]string{iptablesChainExistErr}, ip6tablesCmd, "-w", "-N", chain); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := ns.ensureIp6tablesRule(direction, "-j", chain); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// newIp6tablesChain creates the chain, the rules in the existing chain are removed.
+func (ns netNS) newIp6tablesChain(name string) error {
+	if err := ns.execIgnoreErrs([]string{iptablesChainExistErr}, ip6tablesCmd, "-w", "-N", name); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(ns.xtables(ip6tablesCmd, "-F", name))
+}
+
+func (ns netNS) ensureIp6tablesRule(chain string, rule ...string) error {
+	if ns.command(ip6tablesCmd, append([]string{"-w", "-C", chain}, rule...)...).Run() == nil {
+		return nil
+	}
+
+	return errors.WithStack(ns.xtables(ip6tablesCmd, append([]string{"-A", chain}, rule...)...))
+}
+
+// ip6tablesRules returns the arguments of the rules in the chain, the format is the same as chaos daemon.
+func ip6tablesRules(chain *pb.Chain) [][]string {
+	matchPart := "src"
+	if chain.Direction == pb.Chain_OUTPUT {
+		matchPart = "dst"
+	}
+
+	// ICMP is a different protocol in IPv6
+	protocolAndPort := strings.Replace(chain.Protocol, "--protocol icmp", "--protocol ipv6-icmp", 1)
+	if len(protocolAndPort) > 0 {
+		if len(chain.SourcePorts) > 0 {
+			protocolAndPort += " " + chain.SourcePorts
+		}
+
+		if len(chain.DestinationPorts) > 0 {
+			protocolAndPort += " " + chain.DestinationPorts
+		}
+	}
+
+	var rules [][]string
+	if len(chain.Ipsets) == 0 {
+		rules = append(rules, strings.Fields(fmt.Sprintf("-A %s -j %s %s", chain.Name, chain.Target, protocolAndPort)))
+	}
+
+	for _, ipset := range chain.Ipsets {
+		rules = append(rules, strings.Fields(fmt.Sprintf("-A %s -m set --match-set %s %s -j %s %s",
+			chain.Name, ipset, matchPart, chain.Target, protocolAndPort)))
+	}
+
+	return rules
+}
+
+// syncIPv6TCChains copies the chains created by chaos daemon to classify the traffic for tc filters to ip6tables,
+// so that the tc filters also work on IPv6 traffic. The IPv4 ipsets in the rules are replaced
+// by the IPv6 ipsets of the same experiment, and the rule is skipped if there is no such IPv6 ipset.
+func (s *Server) syncIPv6TCChains(container string) error {
+	ns, err := s.netNSOf(container)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if !ns.ip6tablesAvailable() {
+		return nil
+	}
+
+	ipsets, err := s.ipsetRule.FindByContainer(context.Background(), container)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	ipv6Sets := make(map[string]bool)
+	for _, ipset := range ipsets {
+		if ipset.Family == core.IPFamilyIPv6 {
+			ipv6Sets[ipset.Name] = true
+		}
+	}
+
+	out, err := ns.command(ip6tablesCmd, "-w", "-S").CombinedOutput()
+	if err != nil {
+		return errors.Errorf("ip6tables -S failed: %s, %s", string(out), err)
+	}
+
+	// the rules copied before are removed, the chains are kept since they may be linked to CHAOS-OUTPUT
+	for _, line := range strings.Split(string(out), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "-N" && strings.HasPrefix(fields[1], tcChainPrefix) {
+			if err := ns.xtables(ip6tablesCmd, "-F", fields[1]); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+
+	if len(ipv6Sets) == 0 {
+		return nil
+	}
+
+	out, err = ns.command(iptablesCmd, "-w", "-S").CombinedOutput()
+	if err != nil {
+		return errors.Errorf("iptables -S failed: %s, %s", string(out), err)
+	}
+
+	rules := make(map[string][][]string)
+	var chains []string
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "-A" || !strings.HasPrefix(fields[1], tcChainPrefix) {
+			continue
+		}
+
+		if rule, ok := toIPv6TCRule(fields, ipv6Sets); ok {
+			if _, exists := rules[fields[1]]; !exists {
+				chains = append(chains, fields[1])
+			}
+			rules[fields[1]] = append(rules[fields[1]], rule)
+		}
+	}
+
+	if len(chains) == 0 {
+		return nil
+	}
+
+	if err := ns.initIp6tablesEnv(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, chain := range chains {
+		if err := ns.newIp6tablesChain(chain); err != nil {
+			return errors.WithStack(err)
+		}
+
+		for _, rule := range rules[chain] {
+			if err := ns.xtables(ip6tablesCmd, rule...); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		if err := ns.ensureIp6tablesRule("CHAOS-OUTPUT", "-j", chain); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// toIPv6TCRule converts the rule of iptables to the rule of ip6tables,
+// it returns false if the rule matches an ipset without IPv6 ipset.
+func toIPv6TCRule(fields []string, ipv6Sets map[string]bool) ([]string, bool) {
+	rule := make([]string, 0, len(fields))
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		switch {
+		case field == "--match-set" && i+1 < len(fields):
+			name := core.IPv6SetName(fields[i+1])
+			if !ipv6Sets[name] {
+				return nil, false
+			}
+
+			rule = append(rule, field, name)
+			i++
+			continue
+		case field == "icmp" && i > 0 && fields[i-1] == "-p":
+			field = "ipv6-icmp"
+		}
+
+		rule = append(rule, field)
+	}
+
+	return rule, true
+}
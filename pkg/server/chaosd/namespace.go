@@ -0,0 +1,52 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"context"
+
+	"github.com/pingcap/errors"
+
+	"github.com/chaos-mesh/chaos-mesh/pkg/bpm"
+)
+
+// netNS is the network namespace which the commands executed by chaosd run in,
+// it is represented by the pid of a process in the namespace. The zero value means the host.
+type netNS uint32
+
+const hostNetNS netNS = 0
+
+// netNSOf returns the network namespace of the container, the host namespace is returned for the empty container.
+func (s *Server) netNSOf(container string) (netNS, error) {
+	if len(container) == 0 {
+		return hostNetNS, nil
+	}
+
+	pid, err := s.crClient.GetPidFromContainerID(context.Background(), container)
+	if err != nil {
+		return hostNetNS, errors.WithStack(err)
+	}
+
+	return netNS(pid), nil
+}
+
+// command builds the command which runs in the network namespace
+func (ns netNS) command(name string, args ...string) *bpm.ManagedProcess {
+	builder := bpm.DefaultProcessBuilder(name, args...)
+	if ns != hostNetNS {
+		builder = builder.SetNS(uint32(ns), bpm.NetNS)
+	}
+
+	return builder.Build()
+}
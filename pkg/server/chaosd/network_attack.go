@@ -30,19 +30,28 @@ import (
 	"github.com/chaos-mesh/chaosd/pkg/core"
 )
 
-func (s *Server) NetworkAttack(attack *core.NetworkCommand) (string, error) {
+func (s *Server) NetworkAttack(attack *core.NetworkCommand, origin core.ExperimentOrigin) (string, error) {
 	var (
-		ipsetName string
-		err       error
+		ipsetName, ipset6Name string
+		err                   error
 	)
 	uid := uuid.New().String()
 
+	if attack.Action == core.NetworkProfileAction && attack.NetworkProfile == nil {
+		if attack.NetworkProfile, err = core.FindNetworkProfile(s.networkProfileDir(), attack.Profile); err != nil {
+			return "", errors.WithStack(err)
+		}
+	}
+
 	if err = s.exp.Set(context.Background(), &core.Experiment{
 		Uid:            uid,
 		Status:         core.Created,
 		Kind:           core.NetworkAttack,
 		Action:         attack.Action,
 		RecoverCommand: attack.String(),
+		Recoverable:    true,
+		Name:           origin.Name,
+		Spec:           origin.Spec,
 	}); err != nil {
 		return "", errors.WithStack(err)
 	}
@@ -60,24 +69,28 @@ func (s *Server) NetworkAttack(attack *core.NetworkCommand) (string, error) {
 	}()
 
 	if attack.NeedApplyIPSet() {
-		ipsetName, err = s.applyIPSet(attack, uid)
+		ipsetName, ipset6Name, err = s.applyIPSet(attack, uid)
 		if err != nil {
 			return "", errors.WithStack(err)
 		}
 	}
 
 	if attack.NeedApplyIptables() {
-		if err = s.applyIptables(attack, uid); err != nil {
+		if err = s.applyIptables(attack, ipsetName, ipset6Name, uid); err != nil {
 			return "", errors.WithStack(err)
 		}
 	}
 
 	if attack.NeedApplyTC() {
-		if err = s.applyTC(attack, ipsetName, uid); err != nil {
+		if err = s.applyTC(attack, ipsetName, ipset6Name, uid); err != nil {
 			return "", errors.WithStack(err)
 		}
 	}
 
+	if err = s.setTimer(uid, attack.Duration); err != nil {
+		return "", errors.WithStack(err)
+	}
+
 	if err = s.exp.Update(context.Background(), uid, core.Success, "", attack.String()); err != nil {
 		return "", errors.WithStack(err)
 	}
@@ -85,160 +98,254 @@ func (s *Server) NetworkAttack(attack *core.NetworkCommand) (string, error) {
 	return uid, nil
 }
 
-func (s *Server) applyIPSet(attack *core.NetworkCommand, uid string) (string, error) {
-	ipset, err := attack.ToIPSet(fmt.Sprintf("chaos-%s", uid[:16]))
+// applyIPSet creates the ipsets of the targets, it returns the names of the IPv4 ipset and IPv6 ipset,
+// the name of IPv6 ipset is empty if it is not created.
+func (s *Server) applyIPSet(attack *core.NetworkCommand, uid string) (string, string, error) {
+	ipset, ipset6, err := attack.ToIPSets(fmt.Sprintf("chaos-%s", uid[:16]))
 	if err != nil {
-		return "", errors.WithStack(err)
+		return "", "", errors.WithStack(err)
 	}
 
 	if _, err := s.svr.FlushIPSets(context.Background(), &pb.IPSetsRequest{
-		Ipsets:  []*pb.IPSet{ipset},
-		EnterNS: false,
+		Ipsets:      []*pb.IPSet{ipset},
+		ContainerId: attack.Container,
+		EnterNS:     attack.EnterNS(),
 	}); err != nil {
-		return "", errors.WithStack(err)
+		return "", "", errors.WithStack(err)
 	}
 
 	if err := s.ipsetRule.Set(context.Background(), &core.IPSetRule{
 		Name:       ipset.Name,
 		Cidrs:      strings.Join(ipset.Cidrs, ","),
+		Family:     core.IPFamilyIPv4,
+		Container:  attack.Container,
 		Experiment: uid,
 	}); err != nil {
-		return "", errors.WithStack(err)
+		return "", "", errors.WithStack(err)
 	}
 
-	return ipset.Name, nil
-}
-
-func (s *Server) applyIptables(attack *core.NetworkCommand, uid string) error {
-	iptables, err := s.iptablesRule.List(context.Background())
-	if err != nil {
-		return errors.WithStack(err)
+	if ipset6 == nil {
+		return ipset.Name, "", nil
 	}
-	chains := core.IptablesRuleList(iptables).ToChains()
-	newChain, err := attack.ToChain()
+
+	ns, err := s.netNSOf(attack.Container)
 	if err != nil {
-		return errors.WithStack(err)
+		return "", "", errors.WithStack(err)
 	}
 
-	if newChain != nil {
-		chains = append(chains, newChain)
+	// chaos daemon only supports IPv4 ipset, so the IPv6 ipset is created by chaosd
+	if err := ns.flushIPv6Set(ipset6); err != nil {
+		return "", "", errors.WithStack(err)
 	}
 
-	if _, err := s.svr.SetIptablesChains(context.Background(), &pb.IptablesChainsRequest{
-		Chains:  chains,
-		EnterNS: false,
+	if err := s.ipsetRule.Set(context.Background(), &core.IPSetRule{
+		Name:       ipset6.Name,
+		Cidrs:      strings.Join(ipset6.Cidrs, ","),
+		Family:     core.IPFamilyIPv6,
+		Container:  attack.Container,
+		Experiment: uid,
 	}); err != nil {
-		return errors.WithStack(err)
+		return "", "", errors.WithStack(err)
 	}
 
-	// TODO: cwen0
-	//if err := s.iptablesRule.Set(context.Background(), &core.IptablesRule{
-	//	Name:       newChain.Name,
-	//	IPSets:     strings.Join(newChain.Ipsets, ","),
-	//	Direction:  pb.Chain_Direction_name[int32(newChain.Direction)],
-	//	Experiment: uid,
-	//}); err != nil {
-	//	return errors.WithStack(err)
-	//}
-
-	return nil
+	return ipset.Name, ipset6.Name, nil
 }
 
-func (s *Server) applyTC(attack *core.NetworkCommand, ipset string, uid string) error {
-	tcRules, err := s.tcRule.FindByDevice(context.Background(), attack.Device)
-	if err != nil {
-		return errors.WithStack(err)
+func (s *Server) applyIptables(attack *core.NetworkCommand, ipset string, ipset6 string, uid string) error {
+	name := fmt.Sprintf("chaos-%s", uid[:16])
+
+	if attack.IPFamily != core.IPFamilyIPv6 {
+		iptables, err := s.iptablesRule.FindByContainer(context.Background(), attack.Container)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		chains := core.IptablesRuleList(iptables).ByFamily(core.IPFamilyIPv4).ToChains()
+		newChains, err := attack.ToChains(name, ipset)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		chains = append(chains, newChains...)
+
+		if _, err := s.svr.SetIptablesChains(context.Background(), &pb.IptablesChainsRequest{
+			Chains:      chains,
+			ContainerId: attack.Container,
+			EnterNS:     attack.EnterNS(),
+		}); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := s.recordIptablesChains(newChains, core.IPFamilyIPv4, attack.Container, uid); err != nil {
+			return errors.WithStack(err)
+		}
 	}
 
-	tcs, err := core.TCRuleList(tcRules).ToTCs()
+	newChains, err := attack.ToChains(name, ipset6)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	newTC, err := attack.ToTC(ipset)
+	ns, err := s.netNSOf(attack.Container)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	tcs = append(tcs, newTC)
-	if _, err := s.svr.SetTcs(context.Background(), &pb.TcsRequest{Tcs: tcs, Device: attack.Device, EnterNS: false}); err != nil {
-		return errors.WithStack(err)
+	if len(newChains) == 0 || !needApplyIPv6Iptables(ns, attack, ipset6) {
+		return nil
 	}
 
-	tc := &core.TcParameter{
-		Device: attack.Device,
+	// chaos daemon only supports iptables, so the IPv6 chains are set by chaosd
+	if err := ns.setIp6tablesChains(newChains); err != nil {
+		return errors.WithStack(err)
 	}
-	switch attack.Action {
-	case core.NetworkDelayAction:
-		tc.Delay = &core.DelaySpec{
-			Latency:     attack.Latency,
-			Correlation: attack.Correlation,
-			Jitter:      attack.Jitter,
+
+	return errors.WithStack(s.recordIptablesChains(newChains, core.IPFamilyIPv6, attack.Container, uid))
+}
+
+func (s *Server) recordIptablesChains(chains []*pb.Chain, family string, container string, uid string) error {
+	for _, chain := range chains {
+		if err := s.iptablesRule.Set(context.Background(), &core.IptablesRule{
+			Name:             chain.Name,
+			IPSets:           strings.Join(chain.Ipsets, ","),
+			Direction:        pb.Chain_Direction_name[int32(chain.Direction)],
+			Target:           chain.Target,
+			Protocol:         chain.Protocol,
+			SourcePorts:      chain.SourcePorts,
+			DestinationPorts: chain.DestinationPorts,
+			Family:           family,
+			Container:        container,
+			Experiment:       uid,
+		}); err != nil {
+			return errors.WithStack(err)
 		}
-	case core.NetworkLossAction:
-		tc.Loss = &core.LossSpec{
-			Loss:        attack.Percent,
-			Correlation: attack.Correlation,
+	}
+
+	return nil
+}
+
+func (s *Server) applyTC(attack *core.NetworkCommand, ipset string, ipset6 string, uid string) error {
+	if attack.NeedApplyEgressTC() {
+		newTCs, params, err := attack.ToTCs(ipset)
+		if err != nil {
+			return errors.WithStack(err)
 		}
-	case core.NetworkCorruptAction:
-		tc.Corrupt = &core.CorruptSpec{
-			Corrupt:     attack.Percent,
-			Correlation: attack.Correlation,
+
+		if err := s.appendTCs(attack.Container, attack.Device, newTCs, params, uid); err != nil {
+			return errors.WithStack(err)
 		}
-	case core.NetworkDuplicateAction:
-		tc.Duplicate = &core.DuplicateSpec{
-			Duplicate:   attack.Percent,
-			Correlation: attack.Correlation,
+	}
+
+	if attack.NeedApplyIngressTC() {
+		if err := s.applyIngressTC(attack, ipset, ipset6, uid); err != nil {
+			return errors.WithStack(err)
 		}
-	default:
-		return errors.Errorf("network %s attack not supported", attack.Action)
 	}
 
-	tcString, err := json.Marshal(tc)
+	return nil
+}
+
+// appendTCs applies the new tcs together with the tcs of other experiments on the device, and records the new tcs.
+func (s *Server) appendTCs(container string, device string, newTCs []*pb.Tc, params []*core.TcParameter, uid string) error {
+	tcRules, err := s.tcRule.FindByDevice(context.Background(), container, device)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	if err := s.tcRule.Set(context.Background(), &core.TCRule{
-		Type:       pb.Tc_Type_name[int32(newTC.Type)],
-		Device:     attack.Device,
-		TC:         string(tcString),
-		IPSet:      newTC.Ipset,
-		Protocal:   newTC.Protocol,
-		SourcePort: newTC.SourcePort,
-		EgressPort: newTC.EgressPort,
-		Experiment: uid,
-	}); err != nil {
+	tcs, err := core.TCRuleList(tcRules).ToTCs()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	tcs = append(tcs, newTCs...)
+	if err := s.setTcs(container, device, tcs); err != nil {
 		return errors.WithStack(err)
 	}
 
+	for i, newTC := range newTCs {
+		tcString, err := json.Marshal(params[i])
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := s.tcRule.Set(context.Background(), &core.TCRule{
+			Type:       pb.Tc_Type_name[int32(newTC.Type)],
+			Device:     device,
+			TC:         string(tcString),
+			IPSet:      newTC.Ipset,
+			Protocal:   newTC.Protocol,
+			SourcePort: newTC.SourcePort,
+			EgressPort: newTC.EgressPort,
+			Container:  container,
+			Experiment: uid,
+		}); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
 	return nil
 }
 
 func (s *Server) RecoverNetworkAttack(uid string, attack *core.NetworkCommand) error {
-	if attack.NeedApplyIPSet() {
-		if err := s.recoverIPSet(uid); err != nil {
+	if err := s.recoverNetworkRules(uid, attack); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(s.exp.Update(context.Background(),
+		uid, core.Destroyed, "", attack.String()))
+}
+
+// recoverNetworkRules removes the rules of the experiment and applies the rules left.
+func (s *Server) recoverNetworkRules(uid string, attack *core.NetworkCommand) error {
+	if attack.NeedApplyIptables() {
+		if err := s.recoverIptables(uid, attack.Container); err != nil {
 			return errors.WithStack(err)
 		}
 	}
 
-	if attack.NeedApplyIptables() {
-		if err := s.recoverIptables(uid); err != nil {
+	if attack.NeedApplyIngressTC() {
+		if err := s.recoverIngressTC(uid, attack.Container); err != nil {
 			return errors.WithStack(err)
 		}
 	}
 
 	if attack.NeedApplyTC() {
-		if err := s.recoverTC(uid, attack.Device); err != nil {
+		if err := s.recoverTC(uid, attack); err != nil {
 			return errors.WithStack(err)
 		}
 	}
 
-	return errors.WithStack(s.exp.Update(context.Background(),
-		uid, core.Destroyed, "", attack.String()))
+	// the ipsets are removed at last, because they can't be destroyed while the rules still use them
+	if attack.NeedApplyIPSet() {
+		if err := s.recoverIPSet(uid, attack.Container); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
 }
 
-func (s *Server) recoverIPSet(uid string) error {
+func (s *Server) recoverIPSet(uid string, container string) error {
+	ns, err := s.netNSOf(container)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	rules, err := s.ipsetRule.FindByExperiment(context.Background(), uid)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// the IPv6 ipsets are created by chaosd, so they are also destroyed by chaosd
+	for _, rule := range rules {
+		if rule.Family != core.IPFamilyIPv6 {
+			continue
+		}
+
+		if err := ns.execIgnoreErrs([]string{ipsetNotExistErr}, "ipset", "destroy", rule.Name); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
 	if err := s.ipsetRule.DeleteByExperiment(context.Background(), uid); err != nil {
 		return errors.WithStack(err)
 	}
@@ -246,43 +353,56 @@ func (s *Server) recoverIPSet(uid string) error {
 	return nil
 }
 
-func (s *Server) recoverIptables(uid string) error {
-	if err := s.iptablesRule.DeleteByExperiment(context.Background(), uid); err != nil {
+func (s *Server) recoverIptables(uid string, container string) error {
+	ns, err := s.netNSOf(container)
+	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	iptables, err := s.iptablesRule.List(context.Background())
+	rules, err := s.iptablesRule.FindByExperiment(context.Background(), uid)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	chains := core.IptablesRuleList(iptables).ToChains()
+	// only remove the chains of this experiment, the chains of other experiments keep working
+	for _, rule := range rules {
+		if err := ns.deleteIptablesChain(iptablesCmdOfFamily(rule.Family), rule.ToChain()); err != nil {
+			return errors.WithStack(err)
+		}
+	}
 
-	if _, err := s.svr.SetIptablesChains(context.Background(), &pb.IptablesChainsRequest{
-		Chains:  chains,
-		EnterNS: false,
-	}); err != nil {
+	if err := s.iptablesRule.DeleteByExperiment(context.Background(), uid); err != nil {
 		return errors.WithStack(err)
 	}
 
 	return nil
 }
 
-func (s *Server) recoverTC(uid string, device string) error {
+func (s *Server) recoverTC(uid string, attack *core.NetworkCommand) error {
 	if err := s.tcRule.DeleteByExperiment(context.Background(), uid); err != nil {
 		return errors.WithStack(err)
 	}
 
-	tcRules, err := s.tcRule.FindByDevice(context.Background(), device)
-
-	tcs, err := core.TCRuleList(tcRules).ToTCs()
-	if err != nil {
-		return errors.WithStack(err)
+	// the tcs of the ingress only attack are applied on the IFB devices, which have been removed,
+	// and the egress tcs of the device are not changed by it.
+	if !attack.NeedApplyEgressTC() {
+		return nil
 	}
 
-	if _, err := s.svr.SetTcs(context.Background(), &pb.TcsRequest{Tcs: tcs, Device: device, EnterNS: false}); err != nil {
+	return errors.WithStack(s.reapplyTCs(attack.Container, attack.Device))
+}
+
+// setTcs replaces the tcs on the device in the network namespace of the container,
+// the classifying chains of the filtered tcs are also copied to ip6tables.
+func (s *Server) setTcs(container string, device string, tcs []*pb.Tc) error {
+	if _, err := s.svr.SetTcs(context.Background(), &pb.TcsRequest{
+		Tcs:         tcs,
+		Device:      device,
+		ContainerId: container,
+		EnterNS:     len(container) > 0,
+	}); err != nil {
 		return errors.WithStack(err)
 	}
 
-	return nil
+	return errors.WithStack(s.syncIPv6TCChains(container))
 }
@@ -0,0 +1,44 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"path"
+
+	"github.com/pingcap/errors"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/utils"
+)
+
+// defaultNetworkProfileDir is the directory of user-defined network profiles next to chaosd
+const defaultNetworkProfileDir = "network-profiles"
+
+// NetworkProfiles returns the built-in network profiles and the user-defined network profiles.
+func (s *Server) NetworkProfiles() ([]*core.NetworkProfile, error) {
+	profiles, err := core.LoadNetworkProfiles(s.networkProfileDir())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return profiles, nil
+}
+
+func (s *Server) networkProfileDir() string {
+	if len(s.conf.NetworkProfileDir) > 0 {
+		return s.conf.NetworkProfileDir
+	}
+
+	return path.Join(utils.GetProgramPath(), defaultNetworkProfileDir)
+}
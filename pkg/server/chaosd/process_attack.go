@@ -15,25 +15,30 @@ package chaosd
 
 import (
 	"context"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"os/user"
 	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/chaos-mesh/chaos-mesh/pkg/bpm"
 	"github.com/google/uuid"
 	"github.com/mitchellh/go-ps"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/log"
 
 	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/utils"
 )
 
-func (s *Server) ProcessAttack(attack *core.ProcessCommand) (string, error) {
-	processes, err := ps.Processes()
-	if err != nil {
-		return "", errors.WithStack(err)
-	}
-
+func (s *Server) ProcessAttack(attack *core.ProcessCommand, origin core.ExperimentOrigin) (string, error) {
+	var err error
 	uid := uuid.New().String()
 
 	if err := s.exp.Set(context.Background(), &core.Experiment{
@@ -42,6 +47,9 @@ func (s *Server) ProcessAttack(attack *core.ProcessCommand) (string, error) {
 		Kind:           core.ProcessAttack,
 		Action:         attack.Action,
 		RecoverCommand: attack.String(),
+		Recoverable:    attack.Recoverable(),
+		Name:           origin.Name,
+		Spec:           origin.Spec,
 	}); err != nil {
 		return "", errors.WithStack(err)
 	}
@@ -56,45 +64,335 @@ func (s *Server) ProcessAttack(attack *core.ProcessCommand) (string, error) {
 		if err := s.exp.Update(context.Background(), uid, core.Success, "", attack.String()); err != nil {
 			log.Error("failed to update experiment", zap.Error(err))
 		}
+
+		if attack.Repeated() {
+			s.startRepeat(uid, attack)
+		}
 	}()
 
-	notFound := true
-	for _, p := range processes {
-		if attack.Process == strconv.Itoa(p.Pid()) || attack.Process == p.Executable() {
-			notFound = false
-			switch attack.Signal {
-			case int(syscall.SIGKILL):
-				err = syscall.Kill(p.Pid(), syscall.SIGKILL)
-			case int(syscall.SIGTERM):
-				err = syscall.Kill(p.Pid(), syscall.SIGTERM)
-			case int(syscall.SIGSTOP):
-				err = syscall.Kill(p.Pid(), syscall.SIGSTOP)
-			default:
-				return "", errors.Errorf("signal %d is not supported", attack.Signal)
+	attack.PIDs, err = s.signalProcesses(uid, attack, 1)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if len(attack.PIDs) == 0 {
+		err = errors.Errorf("process %s not found", attack.Selector())
+		return "", errors.WithStack(err)
+	}
+
+	if err = s.setTimer(uid, attack.Duration); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return uid, nil
+}
+
+// signalProcesses sends the signal to the processes selected by the attack and records the events,
+// it returns the pids of the processes received the signal.
+func (s *Server) signalProcesses(uid string, attack *core.ProcessCommand, round int) ([]int, error) {
+	infos, err := s.MatchProcesses(attack)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// the children are killed before their parents, so that the parents can't restart them,
+	// and the parents are stopped before their children, so that the parents can't notice the stopped children
+	if attack.KillChildren && !attack.Stops() {
+		for i, j := 0, len(infos)-1; i < j; i, j = i+1, j-1 {
+			infos[i], infos[j] = infos[j], infos[i]
+		}
+	}
+
+	// the processes are captured before any of them is killed, the attack fails if they can't be restarted
+	if attack.Restart && round == 1 {
+		attack.Snapshots = make([]*core.ProcessSnapshot, 0)
+		for _, info := range restartRoots(infos) {
+			snapshot, err := snapshotProcess(info.Pid)
+			if err != nil {
+				return nil, errors.WithStack(err)
 			}
+			attack.Snapshots = append(attack.Snapshots, snapshot)
+		}
+
+		for _, snapshot := range attack.Snapshots {
+			if err := s.processSnapshot.Set(context.Background(), uid, snapshot); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+	}
+
+	pids := make([]int, 0, len(infos))
+	for _, info := range infos {
+		err := syscall.Kill(info.Pid, syscall.Signal(attack.Signal))
+
+		// the process in the tree may exit after its parent is killed,
+		// and the process may exit before the repeated signal is sent
+		if (attack.KillChildren || attack.Repeated()) && err == syscall.ESRCH {
+			continue
+		}
 
+		if err != nil {
+			return pids, errors.WithStack(err)
+		}
+		pids = append(pids, info.Pid)
+
+		if err := s.processEvent.Set(context.Background(), &core.ProcessEvent{
+			Experiment: uid,
+			Round:      round,
+			Pid:        info.Pid,
+			Cmdline:    info.Cmdline,
+			Signal:     attack.Signal,
+			Time:       time.Now(),
+		}); err != nil {
+			log.Error("failed to record process event", zap.String("uid", uid), zap.Int("pid", info.Pid), zap.Error(err))
+		}
+	}
+
+	return pids, nil
+}
+
+// MatchProcesses returns the processes which will be attacked by the attack. The result may be different
+// from the processes attacked later, since the processes are selected randomly if Count or Percent is set.
+func (s *Server) MatchProcesses(attack *core.ProcessCommand) ([]*core.ProcessInfo, error) {
+	infos, err := s.matchProcesses(attack)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	infos = attack.Sample(infos, rand.New(rand.NewSource(time.Now().UnixNano())))
+	if attack.KillChildren {
+		return processTrees(infos)
+	}
+
+	return infos, nil
+}
+
+// processTrees returns the processes and all their descendants, the parents are ordered before their children.
+func processTrees(roots []*core.ProcessInfo) ([]*core.ProcessInfo, error) {
+	processes, err := ps.Processes()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	graph := utils.NewGraph()
+	for _, p := range processes {
+		graph.Insert(uint32(p.PPid()), uint32(p.Pid()))
+	}
+
+	descendants := make(map[int][]uint32, len(roots))
+	covered := make(map[uint32]bool)
+	for _, root := range roots {
+		descendants[root.Pid] = graph.Flatten(uint32(root.Pid))
+		for _, pid := range descendants[root.Pid] {
+			covered[pid] = true
+		}
+	}
+
+	infos := make([]*core.ProcessInfo, 0, len(roots))
+	for _, root := range roots {
+		// the root is in the tree of another root
+		if covered[uint32(root.Pid)] {
+			continue
+		}
+
+		infos = append(infos, root)
+		for _, pid := range descendants[root.Pid] {
+			// the process may exit while reading
+			info, err := readProcessInfo(int(pid))
 			if err != nil {
-				return "", errors.WithStack(err)
+				continue
 			}
-			attack.PIDs = append(attack.PIDs, p.Pid())
+
+			if root.NsPid == 0 {
+				info.NsPid = 0
+			}
+			infos = append(infos, info)
 		}
 	}
 
-	if notFound {
-		err = errors.Errorf("process %s not found", attack.Process)
-		return "", errors.WithStack(err)
+	return infos, nil
+}
+
+// matchProcesses returns all the processes matching the selectors of the attack without sampling.
+// If the container is specified, only the processes in the pid namespace of the container are searched,
+// and the process is matched with the pid in that namespace.
+func (s *Server) matchProcesses(attack *core.ProcessCommand) ([]*core.ProcessInfo, error) {
+	uid := -1
+	if len(attack.User) > 0 {
+		var err error
+		if uid, err = lookupUid(attack.User); err != nil {
+			return nil, errors.WithStack(err)
+		}
 	}
 
-	return uid, nil
+	selector, err := attack.NewSelector(uid)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	processes, err := ps.Processes()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var pidNS string
+	if len(attack.Container) > 0 {
+		pid, err := s.crClient.GetPidFromContainerID(context.Background(), attack.Container)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if pidNS, err = os.Readlink(bpm.GetNsPath(pid, bpm.PidNS)); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	infos := make([]*core.ProcessInfo, 0)
+	for _, p := range processes {
+		if len(pidNS) > 0 {
+			if ns, err := os.Readlink(bpm.GetNsPath(uint32(p.Pid()), bpm.PidNS)); err != nil || ns != pidNS {
+				continue
+			}
+		}
+
+		// the process may exit while reading
+		info, err := readProcessInfo(p.Pid())
+		if err != nil {
+			continue
+		}
+
+		if len(pidNS) == 0 {
+			info.NsPid = 0
+		}
+
+		if selector.Matches(info) {
+			infos = append(infos, info)
+		}
+	}
+
+	return infos, nil
+}
+
+// lookupUid returns the UID of the user name or the UID
+func lookupUid(name string) (int, error) {
+	if uid, err := strconv.Atoi(name); err == nil {
+		return uid, nil
+	}
+
+	u, err := user.Lookup(name)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	return strconv.Atoi(u.Uid)
+}
+
+// readProcessInfo reads the information of the process from the proc filesystem,
+// NsPid is the pid in the innermost pid namespace the process belongs to.
+func readProcessInfo(pid int) (*core.ProcessInfo, error) {
+	info := &core.ProcessInfo{Pid: pid, NsPid: pid}
+
+	status, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for _, line := range strings.Split(string(status), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		switch fields[0] {
+		case "Name:":
+			info.Name = fields[1]
+		case "PPid:":
+			info.PPid, _ = strconv.Atoi(fields[1])
+		case "Uid:":
+			// the real UID
+			info.Uid, _ = strconv.Atoi(fields[1])
+		case "NSpid:":
+			// the kernel doesn't support the nested pid namespace if it is missing
+			info.NsPid, _ = strconv.Atoi(fields[len(fields)-1])
+		}
+	}
+
+	cmdline, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	info.Cmdline = strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " "))
+
+	stat, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// the executable name in the parentheses may contain spaces, the start time is the 22nd field
+	if i := strings.LastIndex(string(stat), ")"); i >= 0 {
+		if fields := strings.Fields(string(stat)[i+1:]); len(fields) > 19 {
+			info.StartTime, _ = strconv.ParseUint(fields[19], 10, 64)
+		}
+	}
+
+	cgroups, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// each line is hierarchy-ID:controller-list:cgroup-path
+	for _, line := range strings.Split(string(cgroups), "\n") {
+		if fields := strings.SplitN(line, ":", 3); len(fields) == 3 {
+			info.Cgroups = append(info.Cgroups, fields[2])
+		}
+	}
+
+	return info, nil
 }
 
 func (s *Server) RecoverProcessAttack(uid string, attack *core.ProcessCommand) error {
-	if attack.Signal != int(syscall.SIGSTOP) {
-		return errors.Errorf("chaos experiment %s not supported to recover", uid)
+	if !attack.Recoverable() {
+		return errors.Errorf("chaos experiment %s not supported to recover, the processes received %s",
+			uid, core.SignalName(attack.Signal))
+	}
+
+	if attack.Repeated() {
+		s.stopRepeat(uid)
+	}
+
+	if attack.Restart {
+		var err error
+		if attack.Snapshots, err = s.processSnapshot.ListByExperiment(context.Background(), uid); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	for _, snapshot := range attack.Snapshots {
+		// the process has been restarted by the former recovery
+		if snapshot.RestartedPid > 0 {
+			continue
+		}
+
+		pid, err := restartProcess(snapshot)
+		if err != nil {
+			return errors.Errorf("failed to restart process %d: %s", snapshot.Pid, err)
+		}
+
+		// record the restarted process, so it won't be restarted again when recovering again
+		snapshot.RestartedPid = pid
+		if err := s.processSnapshot.Set(context.Background(), uid, snapshot); err != nil {
+			return errors.WithStack(err)
+		}
 	}
 
-	for _, pid := range attack.PIDs {
-		if err := syscall.Kill(pid, syscall.SIGCONT); err != nil {
+	// the pids are ordered from the parents to the children, the children are continued first,
+	// so that the parents see the running children when they are continued.
+	for i := len(attack.PIDs) - 1; i >= 0 && attack.Stops(); i-- {
+		if err := syscall.Kill(attack.PIDs[i], syscall.SIGCONT); err != nil {
+			// the process in the tree may exit after it is stopped
+			if attack.KillChildren && err == syscall.ESRCH {
+				continue
+			}
 			return errors.WithStack(err)
 		}
 	}
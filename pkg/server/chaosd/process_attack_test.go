@@ -0,0 +1,247 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd/chaosdtest"
+	"github.com/chaos-mesh/chaosd/pkg/store/experiment"
+	procstore "github.com/chaos-mesh/chaosd/pkg/store/process"
+)
+
+func TestProcessAttack(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, exp := newTestServer(t)
+	pid := chaosdtest.StartSleepProcess(t)
+
+	uid, err := s.ProcessAttack(&core.ProcessCommand{
+		Action:  core.ProcessStopAction,
+		Process: strconv.Itoa(pid),
+		Signal:  int(syscall.SIGSTOP),
+	}, core.ExperimentOrigin{})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Eventually(func() string { return chaosdtest.ProcessStatus(pid) }).Should(Equal("T"))
+
+	detail, err := s.GetExperiment(uid)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(detail.Status).To(Equal(core.Success))
+	g.Expect(detail.Recoverable).To(BeTrue())
+	g.Expect(detail.ProcessEvents).To(HaveLen(1))
+	g.Expect(detail.ProcessEvents[0].Pid).To(Equal(pid))
+
+	g.Expect(recoverExp(s, exp, uid)).To(Succeed())
+	g.Eventually(func() string { return chaosdtest.ProcessStatus(pid) }).ShouldNot(Equal("T"))
+
+	_, err = s.ProcessAttack(&core.ProcessCommand{
+		Action:  core.ProcessKillAction,
+		Process: "chaosd-process-not-exist",
+		Signal:  int(syscall.SIGKILL),
+	}, core.ExperimentOrigin{})
+	g.Expect(err).Should(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("process chaosd-process-not-exist not found"))
+}
+
+func TestProcessSelectors(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, exp := newTestServer(t)
+	first := chaosdtest.StartSleepProcess(t)
+	second := chaosdtest.StartSleepProcess(t)
+
+	attack := &core.ProcessCommand{
+		Action:  core.ProcessStopAction,
+		Cmdline: "^sleep 60$",
+		PPID:    os.Getpid(),
+		Newest:  true,
+		Signal:  int(syscall.SIGSTOP),
+	}
+
+	processes, err := s.MatchProcesses(attack)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(processes).To(HaveLen(1))
+	g.Expect(processes[0].Pid).To(Equal(second))
+	g.Expect(processes[0].Cmdline).To(Equal("sleep 60"))
+	// the processes are not attacked by the dry run
+	g.Expect(chaosdtest.ProcessStatus(second)).NotTo(Equal("T"))
+
+	uid, err := s.ProcessAttack(attack, core.ExperimentOrigin{})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Eventually(func() string { return chaosdtest.ProcessStatus(second) }).Should(Equal("T"))
+	g.Expect(chaosdtest.ProcessStatus(first)).NotTo(Equal("T"))
+
+	g.Expect(recoverExp(s, exp, uid)).To(Succeed())
+	g.Eventually(func() string { return chaosdtest.ProcessStatus(second) }).ShouldNot(Equal("T"))
+}
+
+func TestProcessTree(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, exp := newTestServer(t)
+
+	cmd := chaosdtest.StartProcess(t, "sh", "-c", "sleep 60 & sleep 60 & wait")
+	t.Cleanup(func() { _ = syscall.Kill(cmd.Process.Pid, syscall.SIGCONT) })
+
+	attack := &core.ProcessCommand{
+		Action:       core.ProcessStopAction,
+		Process:      strconv.Itoa(cmd.Process.Pid),
+		Signal:       int(syscall.SIGSTOP),
+		KillChildren: true,
+	}
+
+	var processes []*core.ProcessInfo
+	g.Eventually(func() []*core.ProcessInfo {
+		processes, _ = s.MatchProcesses(attack)
+		return processes
+	}).Should(HaveLen(3))
+	g.Expect(processes[0].Pid).To(Equal(cmd.Process.Pid))
+	t.Cleanup(func() {
+		for _, p := range processes[1:] {
+			_ = syscall.Kill(p.Pid, syscall.SIGKILL)
+		}
+	})
+
+	uid, err := s.ProcessAttack(attack, core.ExperimentOrigin{})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	for _, p := range processes {
+		g.Eventually(func() string { return chaosdtest.ProcessStatus(p.Pid) }).Should(Equal("T"))
+	}
+
+	g.Expect(recoverExp(s, exp, uid)).To(Succeed())
+	for _, p := range processes {
+		g.Eventually(func() string { return chaosdtest.ProcessStatus(p.Pid) }).ShouldNot(Equal("T"))
+	}
+}
+
+func TestRepeatedProcessAttack(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, exp := newTestServer(t)
+
+	// the supervisor restarts the killed process
+	cmd := chaosdtest.StartProcess(t, "sh", "-c", "while true; do sleep 61; done")
+
+	attack := &core.ProcessCommand{
+		Action:   core.ProcessKillAction,
+		Cmdline:  "^sleep 61$",
+		PPID:     cmd.Process.Pid,
+		Signal:   int(syscall.SIGKILL),
+		Interval: "200ms",
+		Times:    3,
+	}
+	g.Eventually(func() []*core.ProcessInfo {
+		processes, _ := s.MatchProcesses(attack)
+		return processes
+	}).Should(HaveLen(1))
+
+	uid, err := s.ProcessAttack(attack, core.ExperimentOrigin{})
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	var detail *core.ExperimentDetail
+	g.Eventually(func() string {
+		detail, _ = s.GetExperiment(uid)
+		return detail.Status
+	}, 5*time.Second).Should(Equal(core.Destroyed))
+	g.Expect(detail.ProcessEvents).To(HaveLen(3))
+	for i, event := range detail.ProcessEvents {
+		g.Expect(event.Round).To(Equal(i + 1))
+		g.Expect(event.Cmdline).To(Equal("sleep 61"))
+		if i > 0 {
+			// the restarted process is killed
+			g.Expect(event.Pid).NotTo(Equal(detail.ProcessEvents[i-1].Pid))
+		}
+	}
+
+	// the signal is sent until the attack is recovered
+	attack.Times = 0
+	uid, err = s.ProcessAttack(attack, core.ExperimentOrigin{})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Eventually(func() []*core.ProcessEvent {
+		detail, _ = s.GetExperiment(uid)
+		return detail.ProcessEvents
+	}, 5*time.Second).Should(HaveLen(2))
+
+	g.Expect(recoverExp(s, exp, uid)).To(Succeed())
+	detail, err = s.GetExperiment(uid)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Consistently(func() []*core.ProcessEvent {
+		current, _ := s.GetExperiment(uid)
+		return current.ProcessEvents
+	}, time.Second).Should(HaveLen(len(detail.ProcessEvents)))
+}
+
+func TestRestartProcess(t *testing.T) {
+	// the restarted process is paused by the pause command until the resource limits are set
+	if _, err := os.Stat("/usr/local/bin/pause"); err != nil {
+		t.Skip("the pause command is not installed")
+	}
+
+	g := NewGomegaWithT(t)
+
+	db := chaosdtest.NewDB(t)
+	s, exp := chaosdtest.NewServer(db), experiment.NewStore(db)
+
+	cmd := exec.Command("sleep", "62")
+	cmd.Dir = os.TempDir()
+	cmd.Env = []string{"CHAOSD_TEST=restart"}
+	g.Expect(cmd.Start()).To(Succeed())
+	go func() { _ = cmd.Wait() }()
+
+	uid, err := s.ProcessAttack(&core.ProcessCommand{
+		Action:  core.ProcessKillAction,
+		Process: strconv.Itoa(cmd.Process.Pid),
+		Signal:  int(syscall.SIGKILL),
+		Restart: true,
+	}, core.ExperimentOrigin{})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Eventually(func() string { return chaosdtest.ProcessStatus(cmd.Process.Pid) }).Should(BeEmpty())
+
+	g.Expect(recoverExp(s, exp, uid)).To(Succeed())
+
+	// the environment of the process is not in the recover command
+	e, err := exp.FindByUid(context.Background(), uid)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(e.RecoverCommand).NotTo(ContainSubstring("CHAOSD_TEST"))
+
+	snapshots, err := procstore.NewSnapshotStore(db).ListByExperiment(context.Background(), uid)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(snapshots).To(HaveLen(1))
+
+	pid := snapshots[0].RestartedPid
+	g.Expect(pid).To(BeNumerically(">", 0))
+	t.Cleanup(func() { _ = syscall.Kill(pid, syscall.SIGKILL) })
+
+	g.Eventually(func() (string, error) {
+		cmdline, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+		return string(cmdline), err
+	}).Should(Equal("sleep\x0062\x00"))
+	environ, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/environ", pid))
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(string(environ)).To(Equal("CHAOSD_TEST=restart\x00"))
+	cwd, err := os.Readlink(fmt.Sprintf("/proc/%d/cwd", pid))
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(cwd).To(Equal(os.TempDir()))
+}
@@ -0,0 +1,109 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pingcap/log"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+// repeat is a process attack sending the signal repeatedly
+type repeat struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startRepeat sends the signal every interval after the first time, the processes are selected again each time.
+// It stops after the times, or the experiment is recovered, including recovered by another chaosd process.
+func (s *Server) startRepeat(uid string, attack *core.ProcessCommand) {
+	// the interval has been validated
+	interval, _ := time.ParseDuration(attack.Interval)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &repeat{cancel: cancel, done: make(chan struct{})}
+
+	s.repeatLock.Lock()
+	s.repeats[uid] = r
+	s.repeatLock.Unlock()
+
+	go func() {
+		defer func() {
+			s.repeatLock.Lock()
+			delete(s.repeats, uid)
+			s.repeatLock.Unlock()
+			close(r.done)
+		}()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for round := 2; attack.Times == 0 || round <= attack.Times; round++ {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+
+			if !s.repeating(uid) {
+				return
+			}
+
+			pids, err := s.signalProcesses(uid, attack, round)
+			if err != nil {
+				log.Error("failed to send signal repeatedly", zap.String("uid", uid), zap.Int("round", round), zap.Error(err))
+				continue
+			}
+
+			log.Info("send signal repeatedly", zap.String("uid", uid), zap.Int("round", round), zap.Ints("pids", pids))
+		}
+
+		// the experiment is finished after all the times
+		if s.repeating(uid) {
+			if err := s.exp.Update(context.Background(), uid, core.Destroyed, "", attack.String()); err != nil {
+				log.Error("failed to update experiment", zap.String("uid", uid), zap.Error(err))
+			}
+		}
+	}()
+}
+
+// repeating returns false if the experiment has been recovered or failed
+func (s *Server) repeating(uid string) bool {
+	exp, err := s.exp.FindByUid(context.Background(), uid)
+	if err != nil {
+		log.Error("failed to find experiment", zap.String("uid", uid), zap.Error(err))
+		return false
+	}
+
+	return exp.Status == core.Success
+}
+
+// stopRepeat stops sending the signal repeatedly, and waits for the signal being sent
+func (s *Server) stopRepeat(uid string) {
+	s.repeatLock.Lock()
+	r, ok := s.repeats[uid]
+	s.repeatLock.Unlock()
+
+	if !ok {
+		return
+	}
+
+	r.cancel()
+	<-r.done
+}
@@ -0,0 +1,186 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/chaos-mesh/chaos-mesh/pkg/bpm"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+// restartRoots returns the processes to restart, the descendants are restarted by their parents
+func restartRoots(infos []*core.ProcessInfo) []*core.ProcessInfo {
+	pids := make(map[int]bool, len(infos))
+	for _, info := range infos {
+		pids[info.Pid] = true
+	}
+
+	roots := make([]*core.ProcessInfo, 0, len(infos))
+	for _, info := range infos {
+		if !pids[info.PPid] {
+			roots = append(roots, info)
+		}
+	}
+
+	return roots
+}
+
+// snapshotProcess captures the command line, working directory, environment, user and resource limits of the process
+func snapshotProcess(pid int) (*core.ProcessSnapshot, error) {
+	snapshot := &core.ProcessSnapshot{Pid: pid}
+
+	cmdline, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if snapshot.Args = splitNull(cmdline); len(snapshot.Args) == 0 {
+		return nil, errors.Errorf("process %d has no command line, it can't be restarted", pid)
+	}
+
+	if snapshot.Cwd, err = os.Readlink(fmt.Sprintf("/proc/%d/cwd", pid)); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	environ, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/environ", pid))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	snapshot.Env = splitNull(environ)
+
+	status, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for _, line := range strings.Split(string(status), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "Uid:", "Gid:":
+			// the real UID and GID
+			if len(fields) < 2 {
+				continue
+			}
+
+			id, err := strconv.ParseUint(fields[1], 10, 32)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			if fields[0] == "Uid:" {
+				snapshot.Uid = uint32(id)
+			} else {
+				snapshot.Gid = uint32(id)
+			}
+		case "Groups:":
+			for _, field := range fields[1:] {
+				group, err := strconv.ParseUint(field, 10, 32)
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+				snapshot.Groups = append(snapshot.Groups, uint32(group))
+			}
+		}
+	}
+
+	for resource := 0; resource <= unix.RLIMIT_RTTIME; resource++ {
+		var limit unix.Rlimit
+		if err := prlimit(pid, resource, nil, &limit); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		snapshot.Rlimits = append(snapshot.Rlimits, core.Rlimit{Resource: resource, Cur: limit.Cur, Max: limit.Max})
+	}
+
+	return snapshot, nil
+}
+
+// prlimit gets or sets the resource limit of the process
+func prlimit(pid int, resource int, newLimit *unix.Rlimit, oldLimit *unix.Rlimit) error {
+	_, _, errno := unix.RawSyscall6(unix.SYS_PRLIMIT64, uintptr(pid), uintptr(resource),
+		uintptr(unsafe.Pointer(newLimit)), uintptr(unsafe.Pointer(oldLimit)), 0, 0)
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+// splitNull splits the null separated strings in the proc filesystem, such as the cmdline and environ
+func splitNull(data []byte) []string {
+	s := strings.TrimRight(string(data), "\x00")
+	if len(s) == 0 {
+		return nil
+	}
+
+	return strings.Split(s, "\x00")
+}
+
+// restartProcess starts the process captured by the snapshot, and returns the pid of it. The process is paused
+// until the resource limits are set, and it runs in a new session, so it isn't killed when chaosd exits.
+func restartProcess(snapshot *core.ProcessSnapshot) (int, error) {
+	cmd := bpm.DefaultProcessBuilder(snapshot.Args[0], snapshot.Args[1:]...).EnablePause().Build()
+	cmd.Cmd.Dir = snapshot.Cwd
+	cmd.Cmd.Env = snapshot.Env
+	cmd.Cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setsid: true,
+		Credential: &syscall.Credential{
+			Uid:    snapshot.Uid,
+			Gid:    snapshot.Gid,
+			Groups: snapshot.Groups,
+		},
+	}
+
+	backgroundProcessManager := bpm.NewBackgroundProcessManager()
+	if err := backgroundProcessManager.StartProcess(cmd); err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	pid := cmd.Process.Pid
+	for _, limit := range snapshot.Rlimits {
+		if err := prlimit(pid, limit.Resource, &unix.Rlimit{Cur: limit.Cur, Max: limit.Max}, nil); err != nil {
+			if kerr := cmd.Process.Kill(); kerr != nil {
+				log.Error("failed to kill the restarted process", zap.Int("pid", pid), zap.Error(kerr))
+			}
+			return 0, errors.WithStack(err)
+		}
+	}
+
+	if err := resumeProcess(cmd.Process); err != nil {
+		if kerr := cmd.Process.Kill(); kerr != nil {
+			log.Error("failed to kill the restarted process", zap.Int("pid", pid), zap.Error(kerr))
+		}
+		return 0, errors.WithStack(err)
+	}
+
+	log.Info("restart process successfully", zap.Int("pid", snapshot.Pid),
+		zap.Int("restarted pid", pid), zap.Strings("args", snapshot.Args))
+	return pid, nil
+}
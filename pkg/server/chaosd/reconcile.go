@@ -0,0 +1,495 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/shirou/gopsutil/process"
+
+	"github.com/chaos-mesh/chaos-mesh/pkg/chaosdaemon/pb"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+// processStopped is the state of a process stopped by SIGSTOP
+const processStopped = "T"
+
+// Reconcile checks the experiments in created or success status against the real state of the host,
+// the experiments interrupted while being applied will be recovered and marked as error,
+// the lost rules of the running experiments will be applied again,
+// and the experiments can not be restored will be marked as error.
+func (s *Server) Reconcile(cmd *core.ReconcileCommand) ([]*core.ReconcileResult, error) {
+	var exps []*core.Experiment
+	for _, status := range []string{core.Created, core.Success} {
+		es, err := s.exp.ListByStatus(context.Background(), status)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		exps = append(exps, es...)
+	}
+
+	results := make([]*core.ReconcileResult, 0)
+	for _, exp := range exps {
+		result, err := s.reconcileExp(exp)
+		if err != nil {
+			// the other experiments are still reconciled
+			log.Error("failed to check experiment", zap.String("uid", exp.Uid), zap.Error(err))
+			results = append(results, &core.ReconcileResult{
+				Uid:    exp.Uid,
+				Kind:   exp.Kind,
+				Action: exp.Action,
+				Status: exp.Status,
+				Error:  err.Error(),
+			})
+			continue
+		}
+
+		if result == nil {
+			continue
+		}
+
+		if !cmd.DryRun {
+			if err := s.performReconcile(exp, result); err != nil {
+				log.Error("failed to reconcile experiment", zap.String("uid", exp.Uid), zap.Error(err))
+				result.Error = err.Error()
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// reconcileExp returns the operation needed by the experiment, it returns nil if nothing need to do.
+func (s *Server) reconcileExp(exp *core.Experiment) (*core.ReconcileResult, error) {
+	result := &core.ReconcileResult{
+		Uid:    exp.Uid,
+		Kind:   exp.Kind,
+		Action: exp.Action,
+		Status: exp.Status,
+	}
+
+	if exp.Status == core.Created {
+		result.Operation = core.ReconcileRecover
+		result.Message = "chaosd exited while applying the experiment"
+		if exp.Kind == core.StressAttack {
+			// the pid of stress-ng is recorded after it started
+			result.Operation = core.ReconcileMarkError
+			result.Message += ", the stress-ng process may need to be killed manually"
+		}
+
+		return result, nil
+	}
+
+	var (
+		op, msg string
+		err     error
+	)
+	switch exp.Kind {
+	case core.NetworkAttack:
+		attack := &core.NetworkCommand{}
+		if err := json.Unmarshal([]byte(exp.RecoverCommand), attack); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		op, msg, err = s.checkNetworkAttack(exp.Uid, attack)
+	case core.ProcessAttack:
+		attack := &core.ProcessCommand{}
+		if err := json.Unmarshal([]byte(exp.RecoverCommand), attack); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		op, msg = checkProcessAttack(attack)
+	case core.StressAttack:
+		attack := &core.StressCommand{}
+		if err := json.Unmarshal([]byte(exp.RecoverCommand), attack); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		op, msg = checkStressAttack(attack)
+	default:
+		return nil, errors.Errorf("chaos experiment kind %s not found", exp.Kind)
+	}
+
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if len(op) == 0 {
+		return nil, nil
+	}
+
+	result.Operation = op
+	result.Message = msg
+
+	return result, nil
+}
+
+func (s *Server) performReconcile(exp *core.Experiment, result *core.ReconcileResult) error {
+	switch result.Operation {
+	case core.ReconcileReapply:
+		attack := &core.NetworkCommand{}
+		if err := json.Unmarshal([]byte(exp.RecoverCommand), attack); err != nil {
+			return errors.WithStack(err)
+		}
+
+		return errors.WithStack(s.reapplyNetworkRules(exp.Uid, attack))
+	case core.ReconcileRecover:
+		if err := s.recoverInterruptedExp(exp); err != nil {
+			return errors.WithStack(err)
+		}
+	case core.ReconcileMarkError:
+		// the stress-ng process has exited, but the files of the io stressors are left
+		if exp.Kind == core.StressAttack {
+			attack := &core.StressCommand{}
+			if err := json.Unmarshal([]byte(exp.RecoverCommand), attack); err != nil {
+				return errors.WithStack(err)
+			}
+
+			if err := CleanStressFiles(attack); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+
+	return errors.WithStack(s.exp.Update(context.Background(), exp.Uid, core.Error, result.Message, exp.RecoverCommand))
+}
+
+func (s *Server) recoverInterruptedExp(exp *core.Experiment) error {
+	switch exp.Kind {
+	case core.NetworkAttack:
+		attack := &core.NetworkCommand{}
+		if err := json.Unmarshal([]byte(exp.RecoverCommand), attack); err != nil {
+			return errors.WithStack(err)
+		}
+
+		return errors.WithStack(s.recoverNetworkRules(exp.Uid, attack))
+	case core.ProcessAttack:
+		attack := &core.ProcessCommand{}
+		if err := json.Unmarshal([]byte(exp.RecoverCommand), attack); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if !attack.Stops() {
+			return nil
+		}
+
+		return errors.WithStack(s.continueStoppedProcesses(exp, attack))
+	}
+
+	return nil
+}
+
+func (s *Server) checkNetworkAttack(uid string, attack *core.NetworkCommand) (string, string, error) {
+	ns, err := s.netNSOf(attack.Container)
+	if err != nil {
+		// the container may be removed, and the rules are removed with its network namespace
+		return core.ReconcileMarkError, fmt.Sprintf("container %s not found", attack.Container), nil
+	}
+
+	ipsets, err := s.ipsetRule.FindByExperiment(context.Background(), uid)
+	if err != nil {
+		return "", "", errors.WithStack(err)
+	}
+
+	for _, ipset := range ipsets {
+		if !ns.ipsetExists(ipset.Name) {
+			return core.ReconcileReapply, fmt.Sprintf("ipset %s not found", ipset.Name), nil
+		}
+	}
+
+	chains, err := s.iptablesRule.FindByExperiment(context.Background(), uid)
+	if err != nil {
+		return "", "", errors.WithStack(err)
+	}
+
+	for _, chain := range chains {
+		if !ns.iptablesChainExists(iptablesCmdOfFamily(chain.Family), chain.Name) {
+			return core.ReconcileReapply, fmt.Sprintf("iptables chain %s not found", chain.Name), nil
+		}
+	}
+
+	if attack.NeedApplyEgressTC() && !ns.tcQdiscExists(attack.Device) {
+		return core.ReconcileReapply, fmt.Sprintf("tc qdisc on device %s not found", attack.Device), nil
+	}
+
+	ifbs, err := s.ifbRule.FindByExperiment(context.Background(), uid)
+	if err != nil {
+		return "", "", errors.WithStack(err)
+	}
+
+	for _, ifb := range ifbs {
+		if !ns.ifbRedirected(ifb) || !ns.tcQdiscExists(ifb.Name) {
+			return core.ReconcileReapply, fmt.Sprintf("ifb device %s of device %s not found", ifb.Name, ifb.Device), nil
+		}
+	}
+
+	return "", "", nil
+}
+
+func (s *Server) reapplyNetworkRules(uid string, attack *core.NetworkCommand) error {
+	ns, err := s.netNSOf(attack.Container)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	ipsetRules, err := s.ipsetRule.FindByExperiment(context.Background(), uid)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	ipsets := make([]*pb.IPSet, 0, len(ipsetRules))
+	for _, rule := range ipsetRules {
+		ipset := &pb.IPSet{Name: rule.Name}
+		if len(rule.Cidrs) > 0 {
+			ipset.Cidrs = strings.Split(rule.Cidrs, ",")
+		}
+
+		if rule.Family == core.IPFamilyIPv6 {
+			if err := ns.flushIPv6Set(ipset); err != nil {
+				return errors.WithStack(err)
+			}
+			continue
+		}
+
+		ipsets = append(ipsets, ipset)
+	}
+
+	if len(ipsets) > 0 {
+		if _, err := s.svr.FlushIPSets(context.Background(), &pb.IPSetsRequest{
+			Ipsets:      ipsets,
+			ContainerId: attack.Container,
+			EnterNS:     attack.EnterNS(),
+		}); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	iptables, err := s.iptablesRule.FindByContainer(context.Background(), attack.Container)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if ipv4Rules := core.IptablesRuleList(iptables).ByFamily(core.IPFamilyIPv4); len(ipv4Rules) > 0 {
+		if _, err := s.svr.SetIptablesChains(context.Background(), &pb.IptablesChainsRequest{
+			Chains:      ipv4Rules.ToChains(),
+			ContainerId: attack.Container,
+			EnterNS:     attack.EnterNS(),
+		}); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if ipv6Rules := core.IptablesRuleList(iptables).ByFamily(core.IPFamilyIPv6); len(ipv6Rules) > 0 {
+		if err := ns.setIp6tablesChains(ipv6Rules.ToChains()); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if attack.NeedApplyEgressTC() {
+		if err := s.reapplyTCs(attack.Container, attack.Device); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	ifbs, err := s.ifbRule.FindByExperiment(context.Background(), uid)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, ifb := range ifbs {
+		if err := ns.setupIFB(ifb); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if err := s.reapplyTCs(attack.Container, ifb.Name); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// reapplyTCs applies the recorded tcs on the device in the network namespace of the container
+func (s *Server) reapplyTCs(container string, device string) error {
+	tcRules, err := s.tcRule.FindByDevice(context.Background(), container, device)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	tcs, err := core.TCRuleList(tcRules).ToTCs()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(s.setTcs(container, device, tcs))
+}
+
+func checkProcessAttack(attack *core.ProcessCommand) (string, string) {
+	if attack.Repeated() {
+		return core.ReconcileMarkError, "chaosd exited while sending the signal repeatedly"
+	}
+
+	if !attack.Stops() {
+		return "", ""
+	}
+
+	for _, pid := range attack.PIDs {
+		proc, err := process.NewProcess(int32(pid))
+		if err != nil {
+			return core.ReconcileMarkError, fmt.Sprintf("process %d not found", pid)
+		}
+
+		status, err := proc.Status()
+		if err != nil {
+			return core.ReconcileMarkError, fmt.Sprintf("process %d not found", pid)
+		}
+
+		// the process may be continued by others, or the pid is reused after the host rebooted,
+		// it's not safe to stop it again.
+		if status != processStopped {
+			return core.ReconcileMarkError, fmt.Sprintf("process %d is not stopped", pid)
+		}
+	}
+
+	return "", ""
+}
+
+func checkStressAttack(attack *core.StressCommand) (string, string) {
+	if !StressRunning(attack) {
+		return core.ReconcileMarkError, fmt.Sprintf("stress-ng process %d not found", attack.StressngPid)
+	}
+
+	return "", ""
+}
+
+func (s *Server) continueStoppedProcesses(exp *core.Experiment, attack *core.ProcessCommand) error {
+	signaled, err := s.signaledProcesses(exp, attack)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if len(signaled) == 0 {
+		return errors.WithStack(s.continueMatchedProcesses(attack))
+	}
+
+	// the children are continued first, as the recovery does
+	for i := len(signaled) - 1; i >= 0; i-- {
+		pid := signaled[i].Pid
+		proc, err := process.NewProcess(int32(pid))
+		if err != nil {
+			continue
+		}
+
+		if status, err := proc.Status(); err != nil || status != processStopped {
+			continue
+		}
+
+		// the pid is reused by another process if it is started after the signal is sent
+		createTime, err := proc.CreateTime()
+		if err != nil || createTime > signaled[i].Time.UnixNano()/int64(time.Millisecond) {
+			continue
+		}
+
+		if err := syscall.Kill(pid, syscall.SIGCONT); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// signaledProcesses returns the processes signaled by the experiment, in the order they are signaled.
+// Each signal is recorded as a process event right after it is sent, and the pids are recorded on the
+// experiment after all the processes are signaled.
+func (s *Server) signaledProcesses(exp *core.Experiment, attack *core.ProcessCommand) ([]*core.ProcessEvent, error) {
+	events, err := s.processEvent.ListByExperiment(context.Background(), exp.Uid)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	recorded := make(map[int]struct{}, len(events))
+	for _, event := range events {
+		recorded[event.Pid] = struct{}{}
+	}
+
+	for _, pid := range attack.PIDs {
+		if _, ok := recorded[pid]; !ok {
+			events = append(events, &core.ProcessEvent{Pid: pid, Time: exp.UpdatedAt})
+		}
+	}
+
+	return events, nil
+}
+
+// continueMatchedProcesses continues the stopped processes matching the attack,
+// it is used for the experiments created before the signals are recorded.
+func (s *Server) continueMatchedProcesses(attack *core.ProcessCommand) error {
+	infos, err := s.matchProcesses(attack)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if attack.KillChildren {
+		if infos, err = processTrees(infos); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	for _, info := range infos {
+		pid := info.Pid
+		proc, err := process.NewProcess(int32(pid))
+		if err != nil {
+			continue
+		}
+
+		if status, err := proc.Status(); err != nil || status != processStopped {
+			continue
+		}
+
+		if err := syscall.Kill(pid, syscall.SIGCONT); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+func (ns netNS) ipsetExists(name string) bool {
+	return ns.command("ipset", "list", "-n", name).Run() == nil
+}
+
+func (ns netNS) iptablesChainExists(iptablesCmd string, name string) bool {
+	return ns.command(iptablesCmd, "-w", "-S", name).Run() == nil
+}
+
+// tcQdiscExists checks whether the root qdisc created by chaosd exists on the device
+func (ns netNS) tcQdiscExists(device string) bool {
+	cmd := ns.command("tc", "qdisc", "show", "dev", device)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return false
+	}
+
+	// the root qdisc set by chaosd always uses the handle 1:
+	return strings.Contains(string(out), " 1: root")
+}
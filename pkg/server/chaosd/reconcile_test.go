@@ -0,0 +1,68 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd_test
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd/chaosdtest"
+)
+
+func TestReconcileInterruptedProcessAttack(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, exp := newTestServer(t)
+	attacked := chaosdtest.StartProcess(t, "sleep", "63").Process.Pid
+
+	newAttack := func() *core.ProcessCommand {
+		return &core.ProcessCommand{
+			Action:  core.ProcessStopAction,
+			Cmdline: "^sleep 63$",
+			PPID:    os.Getpid(),
+			Signal:  int(syscall.SIGSTOP),
+		}
+	}
+
+	uid, err := s.ProcessAttack(newAttack(), core.ExperimentOrigin{})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Eventually(func() string { return chaosdtest.ProcessStatus(attacked) }).Should(Equal("T"))
+
+	// chaosd exited after the signal is sent, but before the pids are recorded on the experiment
+	g.Expect(exp.Update(context.Background(), uid, core.Created, "", newAttack().String())).To(Succeed())
+
+	// the process matching the attack is stopped by others, such as a debugger
+	stopped := chaosdtest.StartProcess(t, "sleep", "63").Process.Pid
+	g.Expect(syscall.Kill(stopped, syscall.SIGSTOP)).To(Succeed())
+	t.Cleanup(func() { _ = syscall.Kill(stopped, syscall.SIGCONT) })
+	g.Eventually(func() string { return chaosdtest.ProcessStatus(stopped) }).Should(Equal("T"))
+
+	results, err := s.Reconcile(&core.ReconcileCommand{})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(results).To(HaveLen(1))
+	g.Expect(results[0].Operation).To(Equal(core.ReconcileRecover))
+	g.Expect(results[0].Error).To(BeEmpty())
+
+	g.Eventually(func() string { return chaosdtest.ProcessStatus(attacked) }).ShouldNot(Equal("T"))
+	g.Consistently(func() string { return chaosdtest.ProcessStatus(stopped) }, "200ms").Should(Equal("T"))
+
+	e, err := exp.FindByUid(context.Background(), uid)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(e.Status).To(Equal(core.Error))
+}
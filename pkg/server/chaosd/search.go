@@ -38,3 +38,57 @@ func (s *Server) Search(conds *core.SearchCommand) ([]*core.Experiment, error) {
 
 	return exps, nil
 }
+
+// ListExperiments returns a page of the experiments matching the conditions,
+// with the count of all the matched experiments.
+func (s *Server) ListExperiments(conds *core.SearchCommand) (*core.ExperimentList, error) {
+	exps, err := s.exp.ListByConditions(context.Background(), conds)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	total, err := s.exp.CountByConditions(context.Background(), conds)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &core.ExperimentList{
+		Experiments: exps,
+		Total:       total,
+		Offset:      conds.Offset,
+		Limit:       conds.Limit,
+	}, nil
+}
+
+// GetExperiment returns the experiment with the rules applied by it.
+func (s *Server) GetExperiment(uid string) (*core.ExperimentDetail, error) {
+	exp, err := s.exp.FindByUid(context.Background(), uid)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	detail := &core.ExperimentDetail{Experiment: exp}
+	if detail.IPSetRules, err = s.ipsetRule.FindByExperiment(context.Background(), uid); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if detail.IptablesRules, err = s.iptablesRule.FindByExperiment(context.Background(), uid); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if detail.TCRules, err = s.tcRule.FindByExperiment(context.Background(), uid); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if detail.IFBRules, err = s.ifbRule.FindByExperiment(context.Background(), uid); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if exp.Kind == core.ProcessAttack {
+		if detail.ProcessEvents, err = s.processEvent.ListByExperiment(context.Background(), uid); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return detail, nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd_test
+
+import (
+	"strconv"
+	"syscall"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd/chaosdtest"
+)
+
+func TestExperiments(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, exp := newTestServer(t)
+	pid := chaosdtest.StartSleepProcess(t)
+
+	uid, err := s.ProcessAttack(&core.ProcessCommand{
+		Action:  core.ProcessStopAction,
+		Process: strconv.Itoa(pid),
+		Signal:  int(syscall.SIGSTOP),
+	}, core.ExperimentOrigin{})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	defer recoverExp(s, exp, uid)
+
+	_, err = s.ProcessAttack(&core.ProcessCommand{
+		Action:  core.ProcessKillAction,
+		Process: "chaosd-process-not-exist",
+		Signal:  int(syscall.SIGKILL),
+	}, core.ExperimentOrigin{})
+	g.Expect(err).Should(HaveOccurred())
+
+	list, err := s.ListExperiments(&core.SearchCommand{
+		Kind:   core.ProcessAttack,
+		Status: core.Success,
+		Limit:  1,
+	})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(list.Experiments).To(HaveLen(1))
+	g.Expect(list.Experiments[0].Uid).To(Equal(uid))
+	g.Expect(list.Total).To(Equal(int64(1)))
+	g.Expect(list.Limit).To(Equal(uint32(1)))
+
+	list, err = s.ListExperiments(&core.SearchCommand{All: true})
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(list.Total).To(Equal(int64(2)))
+
+	detail, err := s.GetExperiment(uid)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(detail.Uid).To(Equal(uid))
+	g.Expect(detail.Kind).To(Equal(core.ProcessAttack))
+	g.Expect(detail.TCRules).To(BeEmpty())
+
+	_, err = s.GetExperiment("uid-not-exist")
+	g.Expect(errors.Is(err, gorm.ErrRecordNotFound)).To(BeTrue())
+}
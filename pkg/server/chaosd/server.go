@@ -14,6 +14,8 @@
 package chaosd
 
 import (
+	"sync"
+
 	"github.com/chaos-mesh/chaos-mesh/pkg/chaosdaemon"
 
 	"github.com/chaos-mesh/chaosd/pkg/config"
@@ -21,12 +23,21 @@ import (
 )
 
 type Server struct {
-	exp          core.ExperimentStore
-	ipsetRule    core.IPSetRuleStore
-	iptablesRule core.IptablesRuleStore
-	tcRule       core.TCRuleStore
-	conf         *config.Config
-	svr          *chaosdaemon.DaemonServer
+	exp             core.ExperimentStore
+	ipsetRule       core.IPSetRuleStore
+	iptablesRule    core.IptablesRuleStore
+	tcRule          core.TCRuleStore
+	ifbRule         core.IFBRuleStore
+	timer           core.TimerStore
+	processEvent    core.ProcessEventStore
+	processSnapshot core.ProcessSnapshotStore
+	conf            *config.Config
+	svr             *chaosdaemon.DaemonServer
+	crClient        chaosdaemon.ContainerRuntimeInfoClient
+
+	// repeats are the process attacks sending the signal repeatedly by this chaosd, the key is the uid
+	repeatLock sync.Mutex
+	repeats    map[string]*repeat
 }
 
 func NewServer(
@@ -35,14 +46,25 @@ func NewServer(
 	ipset core.IPSetRuleStore,
 	iptables core.IptablesRuleStore,
 	tc core.TCRuleStore,
+	ifb core.IFBRuleStore,
+	timer core.TimerStore,
+	processEvent core.ProcessEventStore,
+	processSnapshot core.ProcessSnapshotStore,
 	svr *chaosdaemon.DaemonServer,
+	crClient chaosdaemon.ContainerRuntimeInfoClient,
 ) *Server {
 	return &Server{
-		conf:         conf,
-		exp:          exp,
-		ipsetRule:    ipset,
-		iptablesRule: iptables,
-		tcRule:       tc,
-		svr:          svr,
+		conf:            conf,
+		exp:             exp,
+		ipsetRule:       ipset,
+		iptablesRule:    iptables,
+		tcRule:          tc,
+		ifbRule:         ifb,
+		timer:           timer,
+		processEvent:    processEvent,
+		processSnapshot: processSnapshot,
+		svr:             svr,
+		crClient:        crClient,
+		repeats:         make(map[string]*repeat),
 	}
 }
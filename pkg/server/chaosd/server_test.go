@@ -0,0 +1,33 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd_test
+
+import (
+	"testing"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd/chaosdtest"
+	"github.com/chaos-mesh/chaosd/pkg/server/utils"
+	"github.com/chaos-mesh/chaosd/pkg/store/experiment"
+)
+
+func newTestServer(t *testing.T) (*chaosd.Server, core.ExperimentStore) {
+	db := chaosdtest.NewDB(t)
+	return chaosdtest.NewServer(db), experiment.NewStore(db)
+}
+
+func recoverExp(s *chaosd.Server, exp core.ExperimentStore, uid string) error {
+	return utils.RecoverExp(exp, s, uid)
+}
@@ -15,21 +15,35 @@ package chaosd
 
 import (
 	"context"
+	"math"
+	"os"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/chaos-mesh/chaos-mesh/api/v1alpha1"
 	"github.com/chaos-mesh/chaos-mesh/pkg/bpm"
+	"github.com/chaos-mesh/chaos-mesh/pkg/chaosdaemon"
 	"github.com/google/uuid"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/log"
 	"github.com/shirou/gopsutil/process"
 	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
 
 	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/stress"
+	"github.com/chaos-mesh/chaosd/pkg/utils"
 )
 
-func (s *Server) StressAttack(attack *core.StressCommand) (string, error) {
+// resumeRetry is the max times to resume the paused process
+const resumeRetry = 1000
+
+// processZombie is the state of a process exited but not waited
+const processZombie = "Z"
+
+func (s *Server) StressAttack(attack *core.StressCommand, origin core.ExperimentOrigin) (string, error) {
 	var err error
 	uid := uuid.New().String()
 
@@ -37,13 +51,21 @@ func (s *Server) StressAttack(attack *core.StressCommand) (string, error) {
 		Uid:            uid,
 		Status:         core.Created,
 		Kind:           core.StressAttack,
+		Action:         attack.Action,
 		RecoverCommand: attack.String(),
+		Recoverable:    true,
+		Name:           origin.Name,
+		Spec:           origin.Spec,
 	}); err != nil {
 		return "", errors.WithStack(err)
 	}
 
 	defer func() {
 		if err != nil {
+			if err := CleanStressFiles(attack); err != nil {
+				log.Error("failed to clean the files of stressors", zap.Error(err))
+			}
+
 			if err := s.exp.Update(context.Background(), uid, core.Error, err.Error(), attack.String()); err != nil {
 				log.Error("failed to update experiment", zap.Error(err))
 			}
@@ -56,32 +78,65 @@ func (s *Server) StressAttack(attack *core.StressCommand) (string, error) {
 		}
 	}()
 
-	stressors := &v1alpha1.Stressors{}
-	if attack.Action == core.StressCPUAction {
-		stressors.CPUStressor = &v1alpha1.CPUStressor{
-			Stressor: v1alpha1.Stressor{
-				Workers: attack.Workers,
-			},
-			Load:    &attack.Load,
-			Options: attack.Options,
+	if attack.Engine, err = stressEngine(attack); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	var memBytes uint64
+	if attack.Action == core.StressMemAction && len(attack.Size) > 0 {
+		if memBytes, err = s.memStressorBytes(attack); err != nil {
+			return "", errors.WithStack(err)
 		}
-	} else if attack.Action == core.StressMemAction {
-		stressors.MemoryStressor = &v1alpha1.MemoryStressor{
-			Stressor: v1alpha1.Stressor{
-				Workers: attack.Workers,
-			},
-			Options: attack.Options,
+	}
+
+	if attack.Action == core.StressIOAction {
+		if attack.TempDir, err = ioTempDir(uid, attack); err != nil {
+			return "", errors.WithStack(err)
 		}
 	}
 
-	stressorsStr, err := stressors.Normalize()
-	if err != nil {
-		return "", err
+	program := "stress-ng"
+	var args []string
+	if attack.Engine == core.StressEngineBuiltin {
+		if program, err = os.Executable(); err != nil {
+			return "", errors.WithStack(err)
+		}
+		if args, err = builtinStressorArgs(attack, memBytes); err != nil {
+			return "", errors.WithStack(err)
+		}
+	} else if attack.Action == core.StressIOAction {
+		args = ioStressorArgs(attack)
+	} else if memBytes > 0 {
+		args = memStressorArgs(attack, memBytes)
+	} else if args, err = normalizeStressors(attack); err != nil {
+		return "", errors.WithStack(err)
 	}
-	log.Info("stressors normalize", zap.String("arguments", stressorsStr))
 
-	cmd := bpm.DefaultProcessBuilder("stress-ng", strings.Fields(stressorsStr)...).
-		Build()
+	if len(attack.Duration) > 0 {
+		// the stressors exit by themselves after the duration, even if chaosd server is down
+		args = append(args, "--timeout", stressTimeout(attack.Duration))
+	}
+
+	builder := bpm.DefaultProcessBuilder(program, args...)
+
+	var containerPid uint32
+	if len(attack.Container) > 0 {
+		containerPid, err = s.crClient.GetPidFromContainerID(context.Background(), attack.Container)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+
+		builder = builder.SetNS(containerPid, bpm.PidNS)
+	}
+
+	// the process is paused until it joins the cgroups and is pinned to the CPUs,
+	// so the stressors forked by it inherit them.
+	paused := len(attack.Container) > 0 || len(attack.Cgroup) > 0 || len(attack.CPUs) > 0
+	if paused {
+		builder = builder.EnablePause()
+	}
+
+	cmd := builder.Build()
 
 	// Build will set SysProcAttr.Pdeathsig = syscall.SIGTERM, and so stress-ng will exit while chaosd exit
 	// so reset it here
@@ -92,27 +147,56 @@ func (s *Server) StressAttack(attack *core.StressCommand) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	log.Info("Start stress-ng process successfully", zap.String("command", cmd.String()))
+	log.Info("Start stress process successfully", zap.String("engine", attack.Engine), zap.String("command", cmd.String()))
 
 	attack.StressngPid = int32(cmd.Process.Pid)
 
+	if paused {
+		if err = placeStressors(cmd.Process.Pid, int(containerPid), attack); err != nil {
+			if kerr := cmd.Process.Kill(); kerr != nil {
+				log.Error("failed to kill stress-ng process", zap.Error(kerr))
+			}
+			return "", errors.WithStack(err)
+		}
+
+		if err = resumeProcess(cmd.Process); err != nil {
+			return "", errors.WithStack(err)
+		}
+	}
+
+	if err = s.setTimer(uid, attack.Duration); err != nil {
+		return "", errors.WithStack(err)
+	}
+
 	return uid, nil
 }
 
 func (s *Server) RecoverStressAttack(uid string, attack *core.StressCommand) error {
-	proc, err := process.NewProcess(attack.StressngPid)
-	if err != nil {
-		return err
+	if !StressRunning(attack) {
+		log.Warn("the stress-ng process has exited, maybe it is killed by manual or its timeout is reached",
+			zap.Int32("pid", attack.StressngPid))
+
+		if err := CleanStressFiles(attack); err != nil {
+			return errors.WithStack(err)
+		}
+		return errors.WithStack(s.exp.Update(context.Background(), uid, core.Destroyed, "", attack.String()))
 	}
 
-	procName, err := proc.Name()
+	proc, err := process.NewProcess(attack.StressngPid)
 	if err != nil {
 		return err
 	}
 
-	if !strings.Contains(procName, "stress-ng") {
-		log.Warn("the process is not stress-ng, maybe it is killed by manual")
-		return nil
+	// sample the memory of the stressors for the last time before they are killed
+	RecordPeakRSS(attack)
+
+	// the stress-ng process running in the container is the child of nsexec, kill it first
+	children, _ := proc.Children()
+	for _, child := range children {
+		if err := child.Kill(); err != nil {
+			log.Error("the stress-ng process kill failed", zap.Error(err))
+			return err
+		}
 	}
 
 	if err := proc.Kill(); err != nil {
@@ -120,9 +204,151 @@ func (s *Server) RecoverStressAttack(uid string, attack *core.StressCommand) err
 		return err
 	}
 
+	if err := CleanStressFiles(attack); err != nil {
+		return errors.WithStack(err)
+	}
+
 	if err := s.exp.Update(context.Background(), uid, core.Destroyed, "", attack.String()); err != nil {
 		return errors.WithStack(err)
 	}
 
 	return nil
 }
+
+// placeStressors moves the paused process into the cgroups of the container or the cgroup of the attack,
+// and pins it to the CPUs of the attack.
+func placeStressors(pid int, containerPid int, attack *core.StressCommand) error {
+	if len(attack.Container) > 0 {
+		if err := joinCgroupsOf(pid, containerPid); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if len(attack.Cgroup) > 0 {
+		if err := joinCgroup(pid, attack.Cgroup); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if len(attack.CPUs) > 0 {
+		// the CPUs have been validated
+		cpus, _ := utils.ParseCPUList(attack.CPUs)
+
+		// the process is allowed to run on the online CPUs of the host, and the CPUs of the cgroups it joins
+		var allowed unix.CPUSet
+		if err := unix.SchedGetaffinity(pid, &allowed); err != nil {
+			return errors.Errorf("failed to get the CPUs of process %d: %v", pid, err)
+		}
+
+		var set unix.CPUSet
+		for _, cpu := range cpus {
+			if !allowed.IsSet(cpu) {
+				return errors.Errorf("cpu %d is not online or not allowed for the stressors", cpu)
+			}
+			set.Set(cpu)
+		}
+
+		if err := unix.SchedSetaffinity(pid, &set); err != nil {
+			return errors.Errorf("failed to pin process %d to CPUs %s: %v", pid, attack.CPUs, err)
+		}
+	}
+
+	return nil
+}
+
+// normalizeStressors returns the arguments of stress-ng for the cpu and mem stressors
+func normalizeStressors(attack *core.StressCommand) ([]string, error) {
+	stressors := &v1alpha1.Stressors{}
+	if attack.Action == core.StressCPUAction {
+		stressors.CPUStressor = &v1alpha1.CPUStressor{
+			Stressor: v1alpha1.Stressor{
+				Workers: attack.Workers,
+			},
+			Load:    &attack.Load,
+			Options: attack.Options,
+		}
+	} else if attack.Action == core.StressMemAction {
+		stressors.MemoryStressor = &v1alpha1.MemoryStressor{
+			Stressor: v1alpha1.Stressor{
+				Workers: attack.Workers,
+			},
+			Options: attack.Options,
+		}
+	}
+
+	stressorsStr, err := stressors.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	log.Info("stressors normalize", zap.String("arguments", stressorsStr))
+
+	return strings.Fields(stressorsStr), nil
+}
+
+// StressRunning returns false if the stress-ng process started by the attack has exited
+func StressRunning(attack *core.StressCommand) bool {
+	proc, err := process.NewProcess(attack.StressngPid)
+	if err != nil {
+		return false
+	}
+
+	if !isStressProcess(proc) {
+		return false
+	}
+
+	// the exited process is a zombie until it is waited by chaosd
+	status, err := proc.Status()
+	return err == nil && status != processZombie
+}
+
+// stressTimeout returns the timeout option of stress-ng in seconds, which is rounded up
+func stressTimeout(duration string) string {
+	// the duration has been validated
+	d, _ := time.ParseDuration(duration)
+
+	// 0 means no timeout for stress-ng
+	seconds := int64(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	return strconv.FormatInt(seconds, 10)
+}
+
+// isStressProcess checks the process started by stress attack, it is stress-ng or chaosd running the builtin stressors,
+// the process is nsexec if the stressors run in the pid namespace of a container.
+func isStressProcess(proc *process.Process) bool {
+	name, err := proc.Name()
+	if err != nil {
+		return false
+	}
+
+	if strings.Contains(name, "stress-ng") || strings.Contains(name, "nsexec") {
+		return true
+	}
+
+	args, err := proc.CmdlineSlice()
+	return err == nil && len(args) > 1 && args[1] == stress.CommandName
+}
+
+// resumeProcess resumes the process paused by the pause command
+func resumeProcess(p *os.Process) error {
+	for i := 0; i < resumeRetry; i++ {
+		if err := p.Signal(syscall.SIGCONT); err != nil {
+			return errors.WithStack(err)
+		}
+
+		time.Sleep(time.Millisecond)
+
+		comm, err := chaosdaemon.ReadCommName(p.Pid)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if strings.TrimSpace(comm) != "pause" {
+			return nil
+		}
+	}
+
+	return errors.Errorf("failed to resume process %d", p.Pid)
+}
@@ -0,0 +1,92 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"os/exec"
+	"strconv"
+
+	"github.com/pingcap/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/stress"
+	"github.com/chaos-mesh/chaosd/pkg/utils"
+)
+
+// stressEngine resolves the engine of the attack, the auto engine falls back to the builtin stressors
+// if stress-ng is not installed.
+func stressEngine(attack *core.StressCommand) (string, error) {
+	switch attack.Engine {
+	case core.StressEngineStressng, core.StressEngineBuiltin:
+		return attack.Engine, nil
+	}
+
+	if _, err := exec.LookPath("stress-ng"); err == nil {
+		return core.StressEngineStressng, nil
+	}
+
+	if len(attack.Options) > 0 {
+		return "", errors.New("stress-ng is not found, it is required by the options")
+	}
+
+	return core.StressEngineBuiltin, nil
+}
+
+// builtinStressorArgs returns the arguments of the chaosd stressor command which runs the builtin stressors
+func builtinStressorArgs(attack *core.StressCommand, memBytes uint64) ([]string, error) {
+	args := []string{
+		stress.CommandName,
+		"--action", attack.Action,
+		"--workers", strconv.Itoa(attack.Workers),
+	}
+
+	switch attack.Action {
+	case core.StressCPUAction:
+		args = append(args, "--load", strconv.Itoa(attack.Load))
+	case core.StressMemAction:
+		args = append(args, "--bytes", strconv.FormatUint(memBytes, 10))
+		if attack.Hang {
+			args = append(args, "--hang")
+		} else if attack.Keep {
+			args = append(args, "--keep")
+		}
+	case core.StressIOAction:
+		bytes, err := ioStressorBytes(attack)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		args = append(args, "--bytes", strconv.FormatUint(bytes, 10), "--temp-path", attack.TempDir)
+		if len(attack.Mode) > 0 {
+			args = append(args, "--mode", attack.Mode)
+		}
+	}
+
+	return args, nil
+}
+
+// ioStressorBytes returns the bytes written by each io stressor, the percent is of the free space
+// of the temporary directory. 0 means the default size.
+func ioStressorBytes(attack *core.StressCommand) (uint64, error) {
+	if len(attack.Size) == 0 {
+		return 0, nil
+	}
+
+	var stat unix.Statfs_t
+	if err := unix.Statfs(attack.TempDir, &stat); err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	return utils.ParseSize(attack.Size, stat.Bavail*uint64(stat.Bsize))
+}
@@ -0,0 +1,126 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/errors"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+// ioTempDir creates the directory of the temporary files written by the io stressors,
+// it is in the path or the mount point of the device of the attack.
+func ioTempDir(uid string, attack *core.StressCommand) (string, error) {
+	base := attack.Path
+	if len(attack.Device) > 0 {
+		var err error
+		if base, err = mountPointOf(attack.Device); err != nil {
+			return "", errors.WithStack(err)
+		}
+	}
+
+	if len(base) == 0 {
+		base = os.TempDir()
+	}
+
+	info, err := os.Stat(base)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if !info.IsDir() {
+		return "", errors.Errorf("path %s is not a directory", base)
+	}
+
+	dir := filepath.Join(base, "chaosd-stress-"+uid)
+	if err := os.Mkdir(dir, 0700); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return dir, nil
+}
+
+// ioStressorArgs returns the arguments of stress-ng for the io stressors
+func ioStressorArgs(attack *core.StressCommand) []string {
+	workers := strconv.Itoa(attack.Workers)
+
+	var args []string
+	switch attack.Mode {
+	case core.StressIOMix:
+		args = []string{"--iomix", workers}
+		if len(attack.Size) > 0 {
+			args = append(args, "--iomix-bytes", attack.Size)
+		}
+	case core.StressIOReadWrite:
+		args = []string{"--hdd", workers, "--hdd-opts", "wr-seq,rd-seq"}
+		if len(attack.Size) > 0 {
+			args = append(args, "--hdd-bytes", attack.Size)
+		}
+	default:
+		args = []string{"--hdd", workers, "--hdd-opts", "wr-seq"}
+		if len(attack.Size) > 0 {
+			args = append(args, "--hdd-bytes", attack.Size)
+		}
+	}
+
+	args = append(args, "--temp-path", attack.TempDir)
+
+	return append(args, attack.Options...)
+}
+
+// mountPointOf returns the first mount point of the block device
+func mountPointOf(device string) (string, error) {
+	dev, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	mounts, err := ioutil.ReadFile("/proc/mounts")
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	for _, line := range strings.Split(string(mounts), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], "/") {
+			continue
+		}
+
+		source, err := filepath.EvalSymlinks(fields[0])
+		if err != nil || source != dev {
+			continue
+		}
+
+		// the spaces in the mount point are escaped as \040
+		return strings.ReplaceAll(fields[1], `\040`, " "), nil
+	}
+
+	return "", errors.Errorf("device %s is not mounted", device)
+}
+
+// CleanStressFiles removes the temporary files written by the io stressors,
+// since they are left if stress-ng is killed by SIGKILL.
+func CleanStressFiles(attack *core.StressCommand) error {
+	if len(attack.TempDir) == 0 {
+		return nil
+	}
+
+	return errors.WithStack(os.RemoveAll(attack.TempDir))
+}
@@ -0,0 +1,125 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/chaos-mesh/chaos-mesh/api/v1alpha1"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/shirou/gopsutil/mem"
+	"github.com/shirou/gopsutil/process"
+	"go.uber.org/zap"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/utils"
+)
+
+// memStressorBytes returns the memory of each memory stressor with the size,
+// the size is shared by the workers, and it must leave the reserve available for the host.
+func (s *Server) memStressorBytes(attack *core.StressCommand) (uint64, error) {
+	vm, err := mem.VirtualMemory()
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	size, err := utils.ParseSize(attack.Size, vm.Available)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	var reserve uint64
+	if len(s.conf.MemoryReserve) > 0 {
+		if reserve, err = utils.ParseSize(s.conf.MemoryReserve, vm.Total); err != nil {
+			return 0, errors.WithStack(err)
+		}
+	}
+
+	if size+reserve > vm.Available {
+		return 0, errors.Errorf("memory size %s (%d bytes) exceeds the available memory %d bytes minus the reserve %s (%d bytes)",
+			attack.Size, size, vm.Available, s.conf.MemoryReserve, reserve)
+	}
+
+	return size / uint64(attack.Workers), nil
+}
+
+// memStressorArgs returns the arguments of stress-ng for the memory stressors with the bytes of each worker
+func memStressorArgs(attack *core.StressCommand, bytes uint64) []string {
+	options := []string{
+		"--vm", strconv.Itoa(attack.Workers),
+		"--vm-bytes", strconv.FormatUint(bytes, 10),
+	}
+	if attack.Hang {
+		// 0 means hanging until the stressor is killed
+		options = append(options, "--vm-hang", "0")
+	} else if attack.Keep {
+		options = append(options, "--vm-keep")
+	}
+
+	stressor := &v1alpha1.MemoryStressor{
+		Stressor: v1alpha1.Stressor{
+			Workers: attack.Workers,
+		},
+		Options: append(options, attack.Options...),
+	}
+
+	// the Normalize of the memory stressor is not used, it adds the bigheap stressor which grows until OOM,
+	// while the size of the vm stressors is limited, so the arguments are the options of the stressor only.
+	// An option may contain its value, such as "--vm-method all".
+	args := strings.Fields(strings.Join(stressor.Options, " "))
+	log.Info("memory stressors", zap.Strings("arguments", args))
+
+	return args
+}
+
+// StressRSS returns the resident memory in bytes of the stress-ng process started by the attack and its workers
+func StressRSS(attack *core.StressCommand) uint64 {
+	proc, err := process.NewProcess(attack.StressngPid)
+	if err != nil {
+		return 0
+	}
+
+	return treeRSS(proc)
+}
+
+func treeRSS(proc *process.Process) uint64 {
+	var rss uint64
+	if info, err := proc.MemoryInfo(); err == nil {
+		rss = info.RSS
+	}
+
+	children, _ := proc.Children()
+	for _, child := range children {
+		rss += treeRSS(child)
+	}
+
+	return rss
+}
+
+// RecordPeakRSS updates the peak RSS of the attack, it returns true if the peak is increased
+func RecordPeakRSS(attack *core.StressCommand) bool {
+	if attack.Action != core.StressMemAction {
+		return false
+	}
+
+	rss := StressRSS(attack)
+	if rss <= attack.PeakRSS {
+		return false
+	}
+
+	attack.PeakRSS = rss
+	return true
+}
@@ -0,0 +1,41 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaosd
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/errors"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+// setTimer persists the deadline of the experiment,
+// the experiment will be recovered by chaosd server after the deadline.
+func (s *Server) setTimer(uid string, duration string) error {
+	if len(duration) == 0 {
+		return nil
+	}
+
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(s.timer.Set(context.Background(), &core.Timer{
+		Experiment: uid,
+		Deadline:   time.Now().Add(d),
+	}))
+}
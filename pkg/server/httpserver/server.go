@@ -14,40 +14,59 @@
 package httpserver
 
 import (
+	"errors"
+	"io/ioutil"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pingcap/log"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 
 	"github.com/chaos-mesh/chaosd/pkg/config"
 	"github.com/chaos-mesh/chaosd/pkg/core"
 	"github.com/chaos-mesh/chaosd/pkg/server/chaosd"
+	"github.com/chaos-mesh/chaosd/pkg/server/scheduler"
 	"github.com/chaos-mesh/chaosd/pkg/server/utils"
+	"github.com/chaos-mesh/chaosd/pkg/server/workflow"
 	"github.com/chaos-mesh/chaosd/pkg/swaggerserver"
 )
 
 type httpServer struct {
-	conf   *config.Config
-	chaos  *chaosd.Server
-	exp    core.ExperimentStore
-	engine *gin.Engine
+	conf      *config.Config
+	chaos     *chaosd.Server
+	exp       core.ExperimentStore
+	workflows *workflow.Engine
+	scheduler *scheduler.Scheduler
+	engine    *gin.Engine
 }
 
 func NewServer(
 	conf *config.Config,
 	chaos *chaosd.Server,
 	exp core.ExperimentStore,
+	workflows *workflow.Engine,
+	scheduler *scheduler.Scheduler,
 ) *httpServer {
 	e := gin.Default()
 	e.Use(utils.MWHandleErrors())
 
-	return &httpServer{
-		conf:   conf,
-		chaos:  chaos,
-		exp:    exp,
-		engine: e,
+	s := &httpServer{
+		conf:      conf,
+		chaos:     chaos,
+		exp:       exp,
+		workflows: workflows,
+		scheduler: scheduler,
+		engine:    e,
 	}
+	handler(s)
+
+	return s
+}
+
+// Handler returns the handler which serves the API of chaosd
+func (s *httpServer) Handler() http.Handler {
+	return s.engine
 }
 
 func Register(s *httpServer) {
@@ -55,8 +74,6 @@ func Register(s *httpServer) {
 		return
 	}
 
-	handler(s)
-
 	go func() {
 		addr := s.conf.Address()
 		log.Debug("starting HTTP server", zap.String("address", addr))
@@ -76,11 +93,42 @@ func handler(s *httpServer) {
 	attack := api.Group("/attack")
 	{
 		attack.POST("/process", s.createProcessAttack)
+		attack.POST("/process/dry-run", s.matchProcesses)
 		attack.POST("/stress", s.createStressAttack)
 		attack.POST("/network", s.createNetworkAttack)
 
 		attack.DELETE("/:uid", s.recoverAttack)
 	}
+
+	apply := api.Group("/apply")
+	{
+		apply.POST("", s.applyExperiment)
+		apply.DELETE("/:name", s.deleteExperiment)
+	}
+
+	experiments := api.Group("/experiments")
+	{
+		experiments.GET("", s.listExperiments)
+		experiments.GET("/:uid", s.getExperiment)
+	}
+
+	workflows := api.Group("/workflow")
+	{
+		workflows.POST("", s.runWorkflow)
+		workflows.GET("", s.listWorkflows)
+		workflows.GET("/:uid", s.getWorkflow)
+		workflows.DELETE("/:uid", s.abortWorkflow)
+	}
+
+	schedules := api.Group("/schedule")
+	{
+		schedules.POST("", s.createSchedule)
+		schedules.GET("", s.listSchedules)
+		schedules.GET("/:uid", s.getSchedule)
+		schedules.PUT("/:uid/pause", s.pauseSchedule)
+		schedules.PUT("/:uid/resume", s.resumeSchedule)
+		schedules.DELETE("/:uid", s.deleteSchedule)
+	}
 }
 
 func (s *httpServer) createProcessAttack(c *gin.Context) {
@@ -90,7 +138,12 @@ func (s *httpServer) createProcessAttack(c *gin.Context) {
 		return
 	}
 
-	uid, err := s.chaos.ProcessAttack(attack)
+	if err := attack.Validate(); err != nil {
+		c.AbortWithError(http.StatusBadRequest, utils.ErrInvalidRequest.WrapWithNoMessage(err))
+		return
+	}
+
+	uid, err := s.chaos.ProcessAttack(attack, core.ExperimentOrigin{})
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, utils.ErrInternalServer.WrapWithNoMessage(err))
 		return
@@ -99,14 +152,41 @@ func (s *httpServer) createProcessAttack(c *gin.Context) {
 	c.JSON(http.StatusOK, utils.AttackSuccessResponse(uid))
 }
 
+func (s *httpServer) matchProcesses(c *gin.Context) {
+	attack := &core.ProcessCommand{}
+	if err := c.ShouldBindJSON(attack); err != nil {
+		c.AbortWithError(http.StatusBadRequest, utils.ErrInternalServer.WrapWithNoMessage(err))
+		return
+	}
+
+	if err := attack.Validate(); err != nil {
+		c.AbortWithError(http.StatusBadRequest, utils.ErrInvalidRequest.WrapWithNoMessage(err))
+		return
+	}
+
+	processes, err := s.chaos.MatchProcesses(attack)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, utils.ErrInternalServer.WrapWithNoMessage(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, processes)
+}
+
 func (s *httpServer) createNetworkAttack(c *gin.Context) {
 	attack := &core.NetworkCommand{}
 	if err := c.ShouldBindJSON(attack); err != nil {
 		c.AbortWithError(http.StatusBadRequest, utils.ErrInternalServer.WrapWithNoMessage(err))
 		return
 	}
+	attack.SetDefault()
+
+	if err := attack.Validate(); err != nil {
+		c.AbortWithError(http.StatusBadRequest, utils.ErrInvalidRequest.WrapWithNoMessage(err))
+		return
+	}
 
-	uid, err := s.chaos.NetworkAttack(attack)
+	uid, err := s.chaos.NetworkAttack(attack, core.ExperimentOrigin{})
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, utils.ErrInternalServer.WrapWithNoMessage(err))
 		return
@@ -122,7 +202,12 @@ func (s *httpServer) createStressAttack(c *gin.Context) {
 		return
 	}
 
-	uid, err := s.chaos.StressAttack(attack)
+	if err := attack.Validate(); err != nil {
+		c.AbortWithError(http.StatusBadRequest, utils.ErrInvalidRequest.WrapWithNoMessage(err))
+		return
+	}
+
+	uid, err := s.chaos.StressAttack(attack, core.ExperimentOrigin{})
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, utils.ErrInternalServer.WrapWithNoMessage(err))
 		return
@@ -141,3 +226,242 @@ func (s *httpServer) recoverAttack(c *gin.Context) {
 
 	c.JSON(http.StatusOK, utils.RecoverSuccessResponse(uid))
 }
+
+func (s *httpServer) listExperiments(c *gin.Context) {
+	conds := &core.SearchCommand{}
+	if err := c.ShouldBindQuery(conds); err != nil {
+		c.AbortWithError(http.StatusBadRequest, utils.ErrInvalidRequest.WrapWithNoMessage(err))
+		return
+	}
+
+	// list all the experiments if neither kind nor status is specified
+	conds.All = len(conds.Kind) == 0 && len(conds.Status) == 0
+
+	if err := conds.Validate(); err != nil {
+		c.AbortWithError(http.StatusBadRequest, utils.ErrInvalidRequest.WrapWithNoMessage(err))
+		return
+	}
+
+	list, err := s.chaos.ListExperiments(conds)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, utils.ErrInternalServer.WrapWithNoMessage(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+func (s *httpServer) getExperiment(c *gin.Context) {
+	uid := c.Param("uid")
+	detail, err := s.chaos.GetExperiment(uid)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.AbortWithError(http.StatusNotFound, utils.ErrNotFound.WrapWithNoMessage(err))
+		return
+	}
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, utils.ErrInternalServer.WrapWithNoMessage(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
+
+// applyExperiment creates the experiment of the spec in the body, which can be in YAML or JSON.
+func (s *httpServer) applyExperiment(c *gin.Context) {
+	data, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, utils.ErrInvalidRequest.WrapWithNoMessage(err))
+		return
+	}
+
+	spec, err := core.ParseExperimentSpec(data)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, utils.ErrInvalidRequest.WrapWithNoMessage(err))
+		return
+	}
+
+	if err := spec.Validate(); err != nil {
+		c.AbortWithError(http.StatusBadRequest, utils.ErrInvalidRequest.WrapWithNoMessage(err))
+		return
+	}
+
+	result, err := utils.ApplyExp(s.exp, s.chaos, spec)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, utils.ErrInternalServer.WrapWithNoMessage(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (s *httpServer) deleteExperiment(c *gin.Context) {
+	result, err := utils.DeleteExp(s.exp, s.chaos, c.Param("name"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.AbortWithError(http.StatusNotFound, utils.ErrNotFound.WrapWithNoMessage(err))
+		return
+	}
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, utils.ErrInternalServer.WrapWithNoMessage(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// runWorkflow starts the workflow of the spec in the body, which can be in YAML or JSON.
+func (s *httpServer) runWorkflow(c *gin.Context) {
+	data, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, utils.ErrInvalidRequest.WrapWithNoMessage(err))
+		return
+	}
+
+	spec, err := core.ParseWorkflowSpec(data)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, utils.ErrInvalidRequest.WrapWithNoMessage(err))
+		return
+	}
+
+	if err := spec.Validate(); err != nil {
+		c.AbortWithError(http.StatusBadRequest, utils.ErrInvalidRequest.WrapWithNoMessage(err))
+		return
+	}
+
+	uid, err := s.workflows.Run(spec)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, utils.ErrInternalServer.WrapWithNoMessage(err))
+		return
+	}
+
+	s.respondWorkflow(c, uid)
+}
+
+func (s *httpServer) listWorkflows(c *gin.Context) {
+	workflows, err := s.workflows.List()
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, utils.ErrInternalServer.WrapWithNoMessage(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, workflows)
+}
+
+func (s *httpServer) getWorkflow(c *gin.Context) {
+	s.respondWorkflow(c, c.Param("uid"))
+}
+
+// abortWorkflow aborts the running workflow, the status of the workflow is responded after it is aborted.
+func (s *httpServer) abortWorkflow(c *gin.Context) {
+	uid := c.Param("uid")
+	err := s.workflows.Abort(uid)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.AbortWithError(http.StatusNotFound, utils.ErrNotFound.WrapWithNoMessage(err))
+		return
+	}
+	var notRunning *workflow.NotRunningError
+	if errors.As(err, &notRunning) {
+		c.AbortWithError(http.StatusConflict, utils.ErrInvalidRequest.WrapWithNoMessage(err))
+		return
+	}
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, utils.ErrInternalServer.WrapWithNoMessage(err))
+		return
+	}
+
+	s.respondWorkflow(c, uid)
+}
+
+func (s *httpServer) respondWorkflow(c *gin.Context, uid string) {
+	status, err := s.workflows.Status(uid)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.AbortWithError(http.StatusNotFound, utils.ErrNotFound.WrapWithNoMessage(err))
+		return
+	}
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, utils.ErrInternalServer.WrapWithNoMessage(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// createSchedule creates the schedule of the spec in the body, which can be in YAML or JSON.
+func (s *httpServer) createSchedule(c *gin.Context) {
+	data, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, utils.ErrInvalidRequest.WrapWithNoMessage(err))
+		return
+	}
+
+	spec, err := core.ParseScheduleSpec(data)
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, utils.ErrInvalidRequest.WrapWithNoMessage(err))
+		return
+	}
+	spec.SetDefault()
+
+	if err := spec.Validate(); err != nil {
+		c.AbortWithError(http.StatusBadRequest, utils.ErrInvalidRequest.WrapWithNoMessage(err))
+		return
+	}
+
+	status, err := s.scheduler.Create(spec)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, utils.ErrInternalServer.WrapWithNoMessage(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+func (s *httpServer) listSchedules(c *gin.Context) {
+	schedules, err := s.scheduler.List()
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, utils.ErrInternalServer.WrapWithNoMessage(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+func (s *httpServer) getSchedule(c *gin.Context) {
+	s.respondSchedule(c, s.scheduler.Status)
+}
+
+func (s *httpServer) pauseSchedule(c *gin.Context) {
+	s.respondSchedule(c, s.scheduler.Pause)
+}
+
+func (s *httpServer) resumeSchedule(c *gin.Context) {
+	s.respondSchedule(c, s.scheduler.Resume)
+}
+
+func (s *httpServer) deleteSchedule(c *gin.Context) {
+	uid := c.Param("uid")
+	err := s.scheduler.Delete(uid)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.AbortWithError(http.StatusNotFound, utils.ErrNotFound.WrapWithNoMessage(err))
+		return
+	}
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, utils.ErrInternalServer.WrapWithNoMessage(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, &utils.Response{Status: http.StatusOK, Message: "delete schedule successfully", UID: uid})
+}
+
+// respondSchedule responds the status of the schedule returned by the operation
+func (s *httpServer) respondSchedule(c *gin.Context, operation func(uid string) (*core.ScheduleStatus, error)) {
+	status, err := operation(c.Param("uid"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.AbortWithError(http.StatusNotFound, utils.ErrNotFound.WrapWithNoMessage(err))
+		return
+	}
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, utils.ErrInternalServer.WrapWithNoMessage(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
@@ -0,0 +1,446 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd"
+	"github.com/chaos-mesh/chaosd/pkg/server/utils"
+)
+
+// Scheduler creates the experiments of the schedules periodically.
+type Scheduler struct {
+	exp       core.ExperimentStore
+	schedules core.ScheduleStore
+	chaos     *chaosd.Server
+	cron      *cron.Cron
+
+	sync.Mutex
+	entries map[string]cron.EntryID
+	// runLock prevents the schedules from running at the same time
+	runLock sync.Mutex
+}
+
+func NewScheduler(
+	exp core.ExperimentStore,
+	schedules core.ScheduleStore,
+	chaos *chaosd.Server,
+) *Scheduler {
+	return &Scheduler{
+		exp:       exp,
+		schedules: schedules,
+		chaos:     chaos,
+		cron:      cron.New(),
+		entries:   make(map[string]cron.EntryID),
+	}
+}
+
+// Register starts the scheduler with the lifecycle of chaosd server,
+// the runs missed while chaosd server was down are started if their starting deadlines are not exceeded.
+func Register(s *Scheduler, lc fx.Lifecycle) {
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			s.start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			select {
+			case <-s.cron.Stop().Done():
+			case <-ctx.Done():
+			}
+			return nil
+		},
+	})
+}
+
+// Create creates the schedule of the spec, the spec should be validated.
+func (s *Scheduler) Create(spec *core.ScheduleSpec) (*core.ScheduleStatus, error) {
+	schedule := &core.Schedule{
+		Uid:  uuid.New().String(),
+		Name: spec.Name,
+		Spec: spec.String(),
+	}
+	if err := s.schedules.Set(context.Background(), schedule); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := s.add(schedule); err != nil {
+		return nil, err
+	}
+
+	return s.Status(schedule.Uid)
+}
+
+// List returns all the schedules, the experiments created by them are not included.
+func (s *Scheduler) List() ([]*core.ScheduleStatus, error) {
+	schedules, err := s.schedules.List(context.Background())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	statuses := make([]*core.ScheduleStatus, 0, len(schedules))
+	for _, schedule := range schedules {
+		statuses = append(statuses, &core.ScheduleStatus{
+			Schedule:         schedule,
+			NextScheduleTime: nextScheduleTime(schedule),
+		})
+	}
+
+	return statuses, nil
+}
+
+// Status returns the schedule and the experiments created by it, it waits for the running run of
+// schedules, so that the experiments replaced by the run have been cleaned by the history limit.
+func (s *Scheduler) Status(uid string) (*core.ScheduleStatus, error) {
+	s.runLock.Lock()
+	defer s.runLock.Unlock()
+
+	schedule, err := s.schedules.FindByUid(context.Background(), uid)
+	if err != nil {
+		return nil, err
+	}
+
+	exps, err := s.exp.ListBySchedule(context.Background(), uid)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &core.ScheduleStatus{
+		Schedule:         schedule,
+		NextScheduleTime: nextScheduleTime(schedule),
+		Experiments:      exps,
+	}, nil
+}
+
+// Pause stops creating experiments by the schedule, the running experiments are not recovered.
+func (s *Scheduler) Pause(uid string) (*core.ScheduleStatus, error) {
+	if err := s.setPaused(uid, true); err != nil {
+		return nil, err
+	}
+
+	return s.Status(uid)
+}
+
+// Resume continues creating experiments by the schedule, the runs missed while it is paused are skipped.
+func (s *Scheduler) Resume(uid string) (*core.ScheduleStatus, error) {
+	if err := s.setPaused(uid, false); err != nil {
+		return nil, err
+	}
+
+	return s.Status(uid)
+}
+
+// setPaused pauses or resumes the schedule. It holds the run lock,
+// otherwise a concurrent run may save its stale copy of the schedule and undo the change.
+func (s *Scheduler) setPaused(uid string, paused bool) error {
+	s.runLock.Lock()
+	defer s.runLock.Unlock()
+
+	schedule, err := s.schedules.FindByUid(context.Background(), uid)
+	if err != nil {
+		return err
+	}
+
+	if schedule.Paused == paused {
+		return nil
+	}
+
+	schedule.Paused = paused
+	if err := s.schedules.Set(context.Background(), schedule); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if paused {
+		s.remove(uid)
+		return nil
+	}
+
+	return s.add(schedule)
+}
+
+// Delete deletes the schedule and recovers its running experiments,
+// the experiments created by it are kept.
+func (s *Scheduler) Delete(uid string) error {
+	if _, err := s.schedules.FindByUid(context.Background(), uid); err != nil {
+		return err
+	}
+	s.remove(uid)
+
+	s.runLock.Lock()
+	defer s.runLock.Unlock()
+
+	if err := s.schedules.Delete(context.Background(), uid); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return s.recoverRunning(uid)
+}
+
+func (s *Scheduler) start() {
+	schedules, err := s.schedules.List(context.Background())
+	if err != nil {
+		log.Error("failed to list schedules", zap.Error(err))
+	}
+
+	now := time.Now()
+	for _, schedule := range schedules {
+		if schedule.Paused {
+			continue
+		}
+
+		s.catchUp(schedule, now)
+
+		if err := s.add(schedule); err != nil {
+			log.Error("failed to add schedule", zap.String("uid", schedule.Uid), zap.Error(err))
+		}
+	}
+
+	s.cron.Start()
+}
+
+// catchUp starts the latest run missed since the last schedule time, if it is not later than the starting deadline.
+func (s *Scheduler) catchUp(schedule *core.Schedule, now time.Time) {
+	spec, err := scheduleSpec(schedule)
+	if err != nil {
+		log.Error("failed to parse schedule", zap.String("uid", schedule.Uid), zap.Error(err))
+		return
+	}
+
+	last := schedule.CreatedAt
+	if schedule.LastScheduleTime != nil {
+		last = *schedule.LastScheduleTime
+	}
+
+	missed, ok := spec.MissedRun(last, now)
+	if !ok {
+		return
+	}
+
+	log.Info("start the missed run of schedule", zap.String("uid", schedule.Uid), zap.Time("missed", missed))
+	s.run(schedule.Uid)
+}
+
+func (s *Scheduler) add(schedule *core.Schedule) error {
+	spec, err := scheduleSpec(schedule)
+	if err != nil {
+		return err
+	}
+
+	cronSchedule, err := spec.Schedule()
+	if err != nil {
+		return err
+	}
+
+	uid := schedule.Uid
+	id := s.cron.Schedule(cronSchedule, cron.FuncJob(func() {
+		s.run(uid)
+	}))
+
+	s.Lock()
+	s.entries[uid] = id
+	s.Unlock()
+
+	return nil
+}
+
+func (s *Scheduler) remove(uid string) {
+	s.Lock()
+	defer s.Unlock()
+
+	if id, ok := s.entries[uid]; ok {
+		s.cron.Remove(id)
+		delete(s.entries, uid)
+	}
+}
+
+// run creates an experiment of the schedule by the concurrency policy
+func (s *Scheduler) run(uid string) {
+	s.runLock.Lock()
+	defer s.runLock.Unlock()
+
+	schedule, err := s.schedules.FindByUid(context.Background(), uid)
+	if err != nil {
+		log.Warn("schedule not found", zap.String("uid", uid), zap.Error(err))
+		return
+	}
+
+	if schedule.Paused {
+		return
+	}
+
+	spec, err := scheduleSpec(schedule)
+	if err != nil {
+		log.Error("failed to parse schedule", zap.String("uid", uid), zap.Error(err))
+		return
+	}
+
+	running, err := s.running(uid)
+	if err != nil {
+		log.Error("failed to list experiments of schedule", zap.String("uid", uid), zap.Error(err))
+		return
+	}
+
+	switch spec.ConcurrencyPolicy {
+	case core.ConcurrencyForbid:
+		if len(running) > 0 {
+			log.Info("skip the run of schedule, the last experiment is still running",
+				zap.String("uid", uid), zap.String("experiment", running[0].Uid))
+			return
+		}
+	case core.ConcurrencyReplace:
+		if err := s.recoverRunning(uid); err != nil {
+			log.Error("failed to replace the running experiments of schedule", zap.String("uid", uid), zap.Error(err))
+			return
+		}
+	}
+
+	now := time.Now()
+	schedule.LastScheduleTime = &now
+	if err := s.schedules.Set(context.Background(), schedule); err != nil {
+		log.Error("failed to update schedule", zap.String("uid", uid), zap.Error(err))
+	}
+
+	expUid, err := s.createExperiment(schedule, spec)
+	if err != nil {
+		log.Error("failed to create experiment of schedule", zap.String("uid", uid), zap.Error(err))
+		return
+	}
+	log.Info("create experiment of schedule", zap.String("uid", uid), zap.String("experiment", expUid))
+
+	if err := s.cleanHistory(uid, *spec.HistoryLimit); err != nil {
+		log.Error("failed to clean the history of schedule", zap.String("uid", uid), zap.Error(err))
+	}
+}
+
+func (s *Scheduler) createExperiment(schedule *core.Schedule, spec *core.ScheduleSpec) (string, error) {
+	cmd, err := spec.Command()
+	if err != nil {
+		return "", err
+	}
+
+	uid, err := utils.CreateExp(s.chaos, cmd, core.ExperimentOrigin{})
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.exp.UpdateSchedule(context.Background(), uid, schedule.Uid); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return uid, nil
+}
+
+// running returns the running experiments of the schedule, the latest one is the first
+func (s *Scheduler) running(uid string) ([]*core.Experiment, error) {
+	exps, err := s.exp.ListBySchedule(context.Background(), uid)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	running := make([]*core.Experiment, 0)
+	for _, exp := range exps {
+		if isRunning(exp) {
+			running = append(running, exp)
+		}
+	}
+
+	return running, nil
+}
+
+func (s *Scheduler) recoverRunning(uid string) error {
+	running, err := s.running(uid)
+	if err != nil {
+		return err
+	}
+
+	for _, exp := range running {
+		if err := utils.RecoverExp(s.exp, s.chaos, exp.Uid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cleanHistory deletes the finished experiments of the schedule except the latest ones within the limit
+func (s *Scheduler) cleanHistory(uid string, limit int) error {
+	exps, err := s.exp.ListBySchedule(context.Background(), uid)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	finished := 0
+	for _, exp := range exps {
+		if isRunning(exp) {
+			continue
+		}
+
+		if finished++; finished <= limit {
+			continue
+		}
+
+		if err := s.exp.Delete(context.Background(), exp.Uid); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// isRunning returns true if the experiment is applied and can be recovered,
+// the experiments such as killing processes are finished once they are applied.
+func isRunning(exp *core.Experiment) bool {
+	return exp.Status == core.Success && utils.Recoverable(exp)
+}
+
+func scheduleSpec(schedule *core.Schedule) (*core.ScheduleSpec, error) {
+	spec := &core.ScheduleSpec{}
+	if err := json.Unmarshal([]byte(schedule.Spec), spec); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	spec.SetDefault()
+
+	return spec, nil
+}
+
+func nextScheduleTime(schedule *core.Schedule) *time.Time {
+	if schedule.Paused {
+		return nil
+	}
+
+	spec, err := scheduleSpec(schedule)
+	if err != nil {
+		return nil
+	}
+
+	cronSchedule, err := spec.Schedule()
+	if err != nil {
+		return nil
+	}
+
+	next := cronSchedule.Next(time.Now())
+	return &next
+}
@@ -0,0 +1,85 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/fx/fxtest"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd/chaosdtest"
+	"github.com/chaos-mesh/chaosd/pkg/store/experiment"
+	"github.com/chaos-mesh/chaosd/pkg/store/schedule"
+)
+
+func TestScheduler(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	db := chaosdtest.NewDB(t)
+	sched := NewScheduler(experiment.NewStore(db), schedule.NewStore(db), chaosdtest.NewServer(db))
+
+	lc := fxtest.NewLifecycle(t)
+	Register(sched, lc)
+	lc.RequireStart()
+	defer lc.RequireStop()
+
+	pid := chaosdtest.StartSleepProcess(t)
+
+	spec, err := core.ParseScheduleSpec([]byte(fmt.Sprintf(`
+name: stop-sleep
+cron: "@every 1s"
+duration: 1h
+concurrencyPolicy: replace
+historyLimit: 1
+kind: process
+spec: {action: stop, process: %d, signal: 19}
+`, pid)))
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	status, err := sched.Create(spec)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(status.NextScheduleTime).NotTo(BeNil())
+	uid := status.Uid
+
+	// the running experiment is replaced by the next run, and only one finished experiment is kept
+	g.Eventually(func() []*core.Experiment {
+		status, _ = sched.Status(uid)
+		return status.Experiments
+	}, 5*time.Second).Should(HaveLen(2))
+	g.Expect(chaosdtest.ProcessStatus(pid)).To(Equal("T"))
+	g.Consistently(func() int {
+		status, _ = sched.Status(uid)
+		return len(status.Experiments)
+	}, 1500*time.Millisecond).Should(Equal(2))
+
+	status, err = sched.Pause(uid)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(status.Paused).To(BeTrue())
+	g.Expect(status.NextScheduleTime).To(BeNil())
+
+	status, err = sched.Resume(uid)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(status.Paused).To(BeFalse())
+
+	// the running experiment is recovered when the schedule is deleted
+	g.Expect(sched.Delete(uid)).To(Succeed())
+	g.Expect(chaosdtest.ProcessStatus(pid)).NotTo(Equal("T"))
+
+	_, err = sched.Status(uid)
+	g.Expect(err).Should(HaveOccurred())
+}
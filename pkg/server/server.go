@@ -14,15 +14,24 @@
 package server
 
 import (
+	"context"
 	"os"
 
 	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/log"
 
 	"github.com/chaos-mesh/chaos-mesh/pkg/chaosdaemon"
 
+	"github.com/chaos-mesh/chaosd/pkg/core"
 	"github.com/chaos-mesh/chaosd/pkg/crclient"
 	"github.com/chaos-mesh/chaosd/pkg/server/chaosd"
 	"github.com/chaos-mesh/chaosd/pkg/server/httpserver"
+	"github.com/chaos-mesh/chaosd/pkg/server/scheduler"
+	"github.com/chaos-mesh/chaosd/pkg/server/timer"
+	"github.com/chaos-mesh/chaosd/pkg/server/watchdog"
+	"github.com/chaos-mesh/chaosd/pkg/server/workflow"
 )
 
 var Module = fx.Options(
@@ -32,5 +41,38 @@ var Module = fx.Options(
 		crclient.NewNodeCRClient,
 		os.Getpid,
 		chaosdaemon.NewDaemonServerWithCRClient,
+		timer.NewTimer,
+		watchdog.NewWatchdog,
+		workflow.NewEngine,
+		scheduler.NewScheduler,
 	),
+	// reconcile the orphaned experiments before recovering the expired experiments
+	fx.Invoke(reconcile),
+	fx.Invoke(timer.Register),
+	fx.Invoke(watchdog.Register),
+	fx.Invoke(workflow.Register),
+	fx.Invoke(scheduler.Register),
 )
+
+// reconcile reconciles the experiments left by the crashed chaosd at startup
+func reconcile(lc fx.Lifecycle, chaos *chaosd.Server) {
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			results, err := chaos.Reconcile(&core.ReconcileCommand{})
+			if err != nil {
+				log.Error("failed to reconcile experiments", zap.Error(err))
+				return nil
+			}
+
+			for _, result := range results {
+				log.Info("reconcile experiment",
+					zap.String("uid", result.Uid),
+					zap.String("operation", result.Operation),
+					zap.String("message", result.Message),
+					zap.String("error", result.Error))
+			}
+
+			return nil
+		},
+	})
+}
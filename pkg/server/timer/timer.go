@@ -0,0 +1,120 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timer
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/log"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd"
+	"github.com/chaos-mesh/chaosd/pkg/server/utils"
+)
+
+// checkInterval is the interval to check whether there are expired experiments
+const checkInterval = time.Second
+
+// Timer recovers the experiments whose deadline has been reached.
+type Timer struct {
+	exp    core.ExperimentStore
+	timers core.TimerStore
+	chaos  *chaosd.Server
+	stopCh chan struct{}
+}
+
+func NewTimer(
+	exp core.ExperimentStore,
+	timers core.TimerStore,
+	chaos *chaosd.Server,
+) *Timer {
+	return &Timer{
+		exp:    exp,
+		timers: timers,
+		chaos:  chaos,
+		stopCh: make(chan struct{}),
+	}
+}
+
+// Register starts the timer with the lifecycle of chaosd server,
+// the experiments expired while chaosd server was down will be recovered at startup.
+func Register(t *Timer, lc fx.Lifecycle) {
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go t.run()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			close(t.stopCh)
+			return nil
+		},
+	})
+}
+
+func (t *Timer) run() {
+	t.recoverExpired()
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			t.recoverExpired()
+		case <-t.stopCh:
+			return
+		}
+	}
+}
+
+func (t *Timer) recoverExpired() {
+	timers, err := t.timers.ListExpired(context.Background(), time.Now())
+	if err != nil {
+		log.Error("failed to list expired timers", zap.Error(err))
+		return
+	}
+
+	for _, timer := range timers {
+		t.recover(timer)
+
+		if err := t.timers.DeleteByExperiment(context.Background(), timer.Experiment); err != nil {
+			log.Error("failed to delete timer", zap.String("uid", timer.Experiment), zap.Error(err))
+		}
+	}
+}
+
+func (t *Timer) recover(timer *core.Timer) {
+	exp, err := t.exp.FindByUid(context.Background(), timer.Experiment)
+	if err != nil {
+		log.Warn("experiment of timer not found", zap.String("uid", timer.Experiment), zap.Error(err))
+		return
+	}
+
+	// the experiment has been recovered manually or failed to apply
+	if exp.Status != core.Success {
+		return
+	}
+
+	if err := utils.RecoverExp(t.exp, t.chaos, exp.Uid); err != nil {
+		log.Error("failed to recover expired experiment", zap.String("uid", exp.Uid), zap.Error(err))
+		return
+	}
+
+	log.Info("recover expired experiment successfully",
+		zap.String("uid", exp.Uid), zap.Time("deadline", timer.Deadline))
+}
@@ -0,0 +1,116 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd"
+)
+
+// applyLock prevents the spec of the same name from being applied concurrently
+var applyLock sync.Mutex
+
+// ApplyExp creates the experiment of the spec. If the experiment with the same name is running,
+// nothing is changed if the spec is the same, otherwise the old experiment is recovered before creating the new one.
+func ApplyExp(expStore core.ExperimentStore, chaos *chaosd.Server, spec *core.ExperimentSpec) (*core.ApplyResult, error) {
+	cmd, err := spec.Command()
+	if err != nil {
+		return nil, err
+	}
+
+	applyLock.Lock()
+	defer applyLock.Unlock()
+
+	operation := core.ApplyCreated
+	exp, err := findRunningExp(expStore, spec.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if exp != nil {
+		if exp.Spec == spec.String() {
+			return &core.ApplyResult{Name: spec.Name, Uid: exp.Uid, Operation: core.ApplyUnchanged}, nil
+		}
+
+		if err := RecoverExp(expStore, chaos, exp.Uid); err != nil {
+			return nil, err
+		}
+		operation = core.ApplyConfigured
+	}
+
+	uid, err := CreateExp(chaos, cmd, core.ExperimentOrigin{Name: spec.Name, Spec: spec.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	return &core.ApplyResult{Name: spec.Name, Uid: uid, Operation: operation}, nil
+}
+
+// CreateExp creates the experiment of the attack command, the origin is recorded on the experiment when it is created.
+func CreateExp(chaos *chaosd.Server, cmd core.AttackCommand, origin core.ExperimentOrigin) (string, error) {
+	switch attack := cmd.(type) {
+	case *core.ProcessCommand:
+		return chaos.ProcessAttack(attack, origin)
+	case *core.NetworkCommand:
+		return chaos.NetworkAttack(attack, origin)
+	case *core.StressCommand:
+		return chaos.StressAttack(attack, origin)
+	default:
+		return "", errors.Errorf("chaos experiment of %T not supported", cmd)
+	}
+}
+
+// DeleteExp recovers the running experiment created by applying the spec of the name.
+func DeleteExp(expStore core.ExperimentStore, chaos *chaosd.Server, name string) (*core.ApplyResult, error) {
+	applyLock.Lock()
+	defer applyLock.Unlock()
+
+	exp, err := findRunningExp(expStore, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if exp == nil {
+		return nil, errors.Wrapf(gorm.ErrRecordNotFound, "running experiment %s", name)
+	}
+
+	if err := RecoverExp(expStore, chaos, exp.Uid); err != nil {
+		return nil, err
+	}
+
+	return &core.ApplyResult{Name: name, Uid: exp.Uid, Operation: core.ApplyDeleted}, nil
+}
+
+// findRunningExp returns the running experiment of the name, nil is returned if it is not found.
+func findRunningExp(expStore core.ExperimentStore, name string) (*core.Experiment, error) {
+	exp, err := expStore.FindByName(context.Background(), name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if exp.Status != core.Success {
+		return nil, nil
+	}
+
+	return exp, nil
+}
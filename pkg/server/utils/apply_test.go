@@ -0,0 +1,82 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd/chaosdtest"
+	"github.com/chaos-mesh/chaosd/pkg/store/experiment"
+)
+
+func TestApplyExp(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	db := chaosdtest.NewDB(t)
+	chaos := chaosdtest.NewServer(db)
+	exp := experiment.NewStore(db)
+
+	pid := chaosdtest.StartSleepProcess(t)
+	name := fmt.Sprintf("stop-sleep-%d", pid)
+
+	spec, err := core.ParseExperimentSpec([]byte(fmt.Sprintf(
+		"name: %s\nkind: process\nspec:\n  action: stop\n  process: %d\n  signal: 19\n", name, pid)))
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	result, err := ApplyExp(exp, chaos, spec)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(result.Operation).To(Equal(core.ApplyCreated))
+	g.Eventually(func() string { return chaosdtest.ProcessStatus(pid) }).Should(Equal("T"))
+
+	// the experiment is not created again by the same spec
+	unchanged, err := ApplyExp(exp, chaos, spec)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(unchanged.Operation).To(Equal(core.ApplyUnchanged))
+	g.Expect(unchanged.Uid).To(Equal(result.Uid))
+
+	// the old experiment is replaced if the spec is changed
+	spec.Spec = []byte(fmt.Sprintf(`{"action": "stop", "process": "%d", "signal": 19, "duration": "1h"}`, pid))
+	configured, err := ApplyExp(exp, chaos, spec)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(configured.Operation).To(Equal(core.ApplyConfigured))
+	g.Expect(configured.Uid).NotTo(Equal(result.Uid))
+
+	old, err := chaos.GetExperiment(result.Uid)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(old.Status).To(Equal(core.Destroyed))
+
+	detail, err := chaos.GetExperiment(configured.Uid)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(detail.Name).To(Equal(name))
+	g.Expect(detail.Spec).To(Equal(spec.String()))
+
+	deleted, err := DeleteExp(exp, chaos, name)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(deleted.Uid).To(Equal(configured.Uid))
+	g.Eventually(func() string { return chaosdtest.ProcessStatus(pid) }).ShouldNot(Equal("T"))
+
+	_, err = DeleteExp(exp, chaos, name)
+	g.Expect(errors.Is(err, gorm.ErrRecordNotFound)).To(BeTrue())
+
+	// the experiment can not be recovered twice
+	err = RecoverExp(exp, chaos, deleted.Uid)
+	g.Expect(err).Should(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("can not recover destroyed experiment"))
+}
@@ -16,7 +16,6 @@ package utils
 import (
 	"context"
 	"encoding/json"
-	"syscall"
 
 	"github.com/pkg/errors"
 
@@ -45,7 +44,7 @@ func RecoverExp(expStore core.ExperimentStore, chaos *chaosd.Server, uid string)
 			return err
 		}
 
-		if pcmd.Signal != int(syscall.SIGSTOP) {
+		if !pcmd.Recoverable() {
 			return errors.Errorf("process attack %s not support to recover", uid)
 		}
 
@@ -76,3 +75,17 @@ func RecoverExp(expStore core.ExperimentStore, chaos *chaosd.Server, uid string)
 
 	return nil
 }
+
+// Recoverable returns false if the experiment can not be recovered, such as the killed processes
+func Recoverable(exp *core.Experiment) bool {
+	if exp.Kind != core.ProcessAttack {
+		return true
+	}
+
+	pcmd := &core.ProcessCommand{}
+	if err := json.Unmarshal([]byte(exp.RecoverCommand), pcmd); err != nil {
+		return false
+	}
+
+	return pcmd.Recoverable()
+}
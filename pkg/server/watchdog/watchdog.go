@@ -0,0 +1,123 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watchdog
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/pingcap/log"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd"
+)
+
+// checkInterval is the interval to check whether the stress-ng processes have exited
+const checkInterval = time.Second
+
+// Watchdog marks the stress experiments destroyed when their stress-ng processes exit by themselves,
+// such as the timeout of stress-ng is reached, or the process is killed by others.
+// It also records the peak RSS of the running memory stressors.
+type Watchdog struct {
+	exp    core.ExperimentStore
+	stopCh chan struct{}
+}
+
+func NewWatchdog(exp core.ExperimentStore) *Watchdog {
+	return &Watchdog{
+		exp:    exp,
+		stopCh: make(chan struct{}),
+	}
+}
+
+// Register starts the watchdog with the lifecycle of chaosd server
+func Register(w *Watchdog, lc fx.Lifecycle) {
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go w.run()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			close(w.stopCh)
+			return nil
+		},
+	})
+}
+
+func (w *Watchdog) run() {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.checkStressAttacks()
+		case <-w.stopCh:
+			return
+		}
+	}
+}
+
+func (w *Watchdog) checkStressAttacks() {
+	exps, err := w.exp.ListByStatus(context.Background(), core.Success)
+	if err != nil {
+		log.Error("failed to list running experiments", zap.Error(err))
+		return
+	}
+
+	for _, exp := range exps {
+		if exp.Kind != core.StressAttack {
+			continue
+		}
+
+		attack := &core.StressCommand{}
+		if err := json.Unmarshal([]byte(exp.RecoverCommand), attack); err != nil {
+			log.Error("failed to unmarshal stress attack", zap.String("uid", exp.Uid), zap.Error(err))
+			continue
+		}
+
+		if chaosd.StressRunning(attack) {
+			w.recordPeakRSS(exp.Uid, attack)
+			continue
+		}
+
+		if err := chaosd.CleanStressFiles(attack); err != nil {
+			log.Error("failed to clean the files of stressors", zap.String("uid", exp.Uid), zap.Error(err))
+			continue
+		}
+
+		if err := w.exp.Update(context.Background(), exp.Uid, core.Destroyed, "", exp.RecoverCommand); err != nil {
+			log.Error("failed to update experiment", zap.String("uid", exp.Uid), zap.Error(err))
+			continue
+		}
+
+		log.Info("stress-ng process exited, the experiment is destroyed",
+			zap.String("uid", exp.Uid), zap.Int32("pid", attack.StressngPid))
+	}
+}
+
+func (w *Watchdog) recordPeakRSS(uid string, attack *core.StressCommand) {
+	if !chaosd.RecordPeakRSS(attack) {
+		return
+	}
+
+	// the command is not updated if the experiment has been recovered meanwhile
+	if err := w.exp.UpdateCommand(context.Background(), uid, core.Success, attack.String()); err != nil {
+		log.Error("failed to update the peak RSS of experiment", zap.String("uid", uid), zap.Error(err))
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watchdog
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd/chaosdtest"
+	"github.com/chaos-mesh/chaosd/pkg/store/experiment"
+)
+
+func TestCheckStressAttacks(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	exp := experiment.NewStore(chaosdtest.NewDB(t))
+	w := NewWatchdog(exp)
+
+	dir, err := ioutil.TempDir("", "chaosd-watchdog")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	// the process named stress-ng keeps running
+	sleep, err := exec.LookPath("sleep")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(os.Symlink(sleep, filepath.Join(dir, "stress-ng"))).To(Succeed())
+	running := chaosdtest.StartProcess(t, filepath.Join(dir, "stress-ng"), "60")
+
+	exited := exec.Command("true")
+	g.Expect(exited.Run()).To(Succeed())
+
+	tempDir := filepath.Join(dir, "chaosd-stress-exited")
+	g.Expect(os.Mkdir(tempDir, 0700)).To(Succeed())
+
+	attacks := map[string]*core.StressCommand{
+		"running": {Action: core.StressCPUAction, StressngPid: int32(running.Process.Pid)},
+		"exited":  {Action: core.StressIOAction, StressngPid: int32(exited.Process.Pid), TempDir: tempDir},
+	}
+	for uid, attack := range attacks {
+		g.Expect(exp.Set(context.Background(), &core.Experiment{
+			Uid:            uid,
+			Status:         core.Success,
+			Kind:           core.StressAttack,
+			Action:         attack.Action,
+			RecoverCommand: attack.String(),
+		})).To(Succeed())
+	}
+
+	w.checkStressAttacks()
+
+	e, err := exp.FindByUid(context.Background(), "running")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(e.Status).To(Equal(core.Success))
+
+	e, err = exp.FindByUid(context.Background(), "exited")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(e.Status).To(Equal(core.Destroyed))
+	// the files of the io stressors are removed
+	_, err = os.Stat(tempDir)
+	g.Expect(os.IsNotExist(err)).To(BeTrue())
+}
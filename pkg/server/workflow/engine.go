@@ -0,0 +1,464 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd"
+	"github.com/chaos-mesh/chaosd/pkg/server/utils"
+)
+
+// Engine runs the workflows, each experiment step creates a normal experiment.
+// The experiments created by a workflow are recovered in reverse order when the workflow ends.
+type Engine struct {
+	exp       core.ExperimentStore
+	workflows core.WorkflowStore
+	chaos     *chaosd.Server
+
+	sync.Mutex
+	runs map[string]*run
+}
+
+// run is a running workflow
+type run struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	// status and message are set if the workflow is canceled, such as aborted
+	status  string
+	message string
+}
+
+func NewEngine(
+	exp core.ExperimentStore,
+	workflows core.WorkflowStore,
+	chaos *chaosd.Server,
+) *Engine {
+	return &Engine{
+		exp:       exp,
+		workflows: workflows,
+		chaos:     chaos,
+		runs:      make(map[string]*run),
+	}
+}
+
+// Register starts the engine with the lifecycle of chaosd server. The workflows interrupted by the crashed
+// chaosd server are marked as failed at startup, and the running workflows fail when chaosd server stops.
+func Register(e *Engine, lc fx.Lifecycle) {
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			e.recoverInterrupted()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			e.cancelAll(core.WorkflowFailed, "chaosd server is stopped")
+			return nil
+		},
+	})
+}
+
+// Run starts the workflow of the spec and returns its uid, the spec should be validated.
+func (e *Engine) Run(spec *core.WorkflowSpec) (string, error) {
+	wf := &core.Workflow{
+		Uid:    uuid.New().String(),
+		Name:   spec.Name,
+		Status: core.WorkflowRunning,
+		Spec:   spec.String(),
+	}
+	if err := e.workflows.Set(context.Background(), wf); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	nodes := make(map[string]*core.WorkflowNode)
+	if err := e.createNodes(wf.Uid, "", spec.Steps, nodes); err != nil {
+		// the workflow never runs, so it is not left running
+		if err := e.workflows.Update(context.Background(), wf.Uid, core.WorkflowFailed, err.Error()); err != nil {
+			log.Error("failed to update workflow", zap.String("uid", wf.Uid), zap.Error(err))
+		}
+		return "", err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &run{cancel: cancel, done: make(chan struct{})}
+
+	e.Lock()
+	e.runs[wf.Uid] = r
+	e.Unlock()
+
+	go e.execute(ctx, r, wf, spec, nodes)
+
+	return wf.Uid, nil
+}
+
+// NotRunningError is returned when aborting a workflow which is not running
+type NotRunningError struct {
+	Status string
+}
+
+func (e *NotRunningError) Error() string {
+	return fmt.Sprintf("can not abort %s workflow", e.Status)
+}
+
+// Abort stops the running workflow, and recovers the experiments created by it in reverse order.
+func (e *Engine) Abort(uid string) error {
+	wf, err := e.workflows.FindByUid(context.Background(), uid)
+	if err != nil {
+		return err
+	}
+
+	if wf.Status != core.WorkflowRunning {
+		return &NotRunningError{Status: wf.Status}
+	}
+
+	e.Lock()
+	r, ok := e.runs[uid]
+	if ok {
+		r.status, r.message = core.WorkflowAborted, ""
+		r.cancel()
+	}
+	e.Unlock()
+
+	if ok {
+		<-r.done
+		return nil
+	}
+
+	// the workflow is not run by this chaosd server, such as the server crashed while running it
+	return e.finish(wf, core.WorkflowAborted, "")
+}
+
+// Status returns the workflow and the states of its steps
+func (e *Engine) Status(uid string) (*core.WorkflowStatus, error) {
+	wf, err := e.workflows.FindByUid(context.Background(), uid)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := e.workflows.ListNodes(context.Background(), uid)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &core.WorkflowStatus{Workflow: wf, Nodes: nodes}, nil
+}
+
+// List returns all the workflows, the latest one is the first
+func (e *Engine) List() ([]*core.Workflow, error) {
+	workflows, err := e.workflows.List(context.Background())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return workflows, nil
+}
+
+// createNodes creates the pending nodes of the steps in the order of the spec
+func (e *Engine) createNodes(workflow, parent string, steps []*core.WorkflowStep, nodes map[string]*core.WorkflowNode) error {
+	for _, step := range steps {
+		node := &core.WorkflowNode{
+			Workflow: workflow,
+			Path:     joinPath(parent, step.Name),
+			Type:     step.Type(),
+			Status:   core.WorkflowPending,
+		}
+		if err := e.workflows.SetNode(context.Background(), node); err != nil {
+			return errors.WithStack(err)
+		}
+		nodes[node.Path] = node
+
+		if err := e.createNodes(workflow, node.Path, step.Children(), nodes); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *Engine) execute(ctx context.Context, r *run, wf *core.Workflow, spec *core.WorkflowSpec, nodes map[string]*core.WorkflowNode) {
+	defer close(r.done)
+
+	err := e.runSerial(ctx, "", spec.Steps, nodes)
+
+	e.Lock()
+	status, message := r.status, r.message
+	e.Unlock()
+
+	switch {
+	case len(status) > 0:
+	case err != nil:
+		status, message = core.WorkflowFailed, err.Error()
+	default:
+		status = core.WorkflowSucceeded
+	}
+
+	if err := e.finish(wf, status, message); err != nil {
+		log.Error("failed to finish workflow", zap.String("uid", wf.Uid), zap.Error(err))
+	}
+
+	// the run is removed after the workflow is finished, so that Abort waits for it instead of finishing it again
+	e.Lock()
+	delete(e.runs, wf.Uid)
+	e.Unlock()
+}
+
+// finish recovers the experiments of the workflow and updates its status
+func (e *Engine) finish(wf *core.Workflow, status, message string) error {
+	if err := e.recoverExperiments(wf.Uid); err != nil {
+		status = core.WorkflowFailed
+		if len(message) > 0 {
+			message += ", "
+		}
+		message += err.Error()
+	}
+
+	if err := e.workflows.Update(context.Background(), wf.Uid, status, message); err != nil {
+		return errors.WithStack(err)
+	}
+
+	log.Info("workflow finished", zap.String("uid", wf.Uid), zap.String("status", status), zap.String("message", message))
+	return nil
+}
+
+func (e *Engine) runStep(ctx context.Context, path string, step *core.WorkflowStep, nodes map[string]*core.WorkflowNode) error {
+	if err := ctx.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	node := nodes[path]
+	startedAt := time.Now()
+	node.Status, node.StartedAt = core.WorkflowRunning, &startedAt
+	e.setNode(node)
+
+	var err error
+	switch step.Type() {
+	case core.WorkflowStepExperiment:
+		err = e.runExperiment(ctx, node, step)
+	case core.WorkflowStepSerial:
+		err = e.runSerial(ctx, path, step.Serial, nodes)
+	case core.WorkflowStepParallel:
+		err = e.runParallel(ctx, path, step.Parallel, nodes)
+	case core.WorkflowStepSuspend:
+		err = sleep(ctx, step)
+	}
+
+	finishedAt := time.Now()
+	node.FinishedAt = &finishedAt
+	switch {
+	case err == nil:
+		node.Status = core.WorkflowSucceeded
+	case errors.Cause(err) == context.Canceled:
+		node.Status = core.WorkflowAborted
+	default:
+		node.Status, node.Message = core.WorkflowFailed, err.Error()
+	}
+	e.setNode(node)
+
+	return err
+}
+
+func (e *Engine) runSerial(ctx context.Context, path string, steps []*core.WorkflowStep, nodes map[string]*core.WorkflowNode) error {
+	for _, step := range steps {
+		if err := e.runStep(ctx, joinPath(path, step.Name), step, nodes); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runParallel runs the steps at the same time, the others are canceled if one of them fails
+func (e *Engine) runParallel(ctx context.Context, path string, steps []*core.WorkflowStep, nodes map[string]*core.WorkflowNode) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(steps))
+	for i, step := range steps {
+		wg.Add(1)
+		go func(i int, step *core.WorkflowStep) {
+			defer wg.Done()
+
+			if errs[i] = e.runStep(ctx, joinPath(path, step.Name), step, nodes); errs[i] != nil {
+				cancel()
+			}
+		}(i, step)
+	}
+	wg.Wait()
+
+	// the failure is returned rather than the cancellation caused by it
+	var err error
+	for _, e := range errs {
+		if e != nil && (err == nil || errors.Cause(err) == context.Canceled) {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// runExperiment creates the experiment of the step, the experiment is recovered after the duration if it is set.
+func (e *Engine) runExperiment(ctx context.Context, node *core.WorkflowNode, step *core.WorkflowStep) error {
+	cmd, err := step.Command()
+	if err != nil {
+		return err
+	}
+
+	uid, err := utils.CreateExp(e.chaos, cmd, core.ExperimentOrigin{})
+	if err != nil {
+		return errors.Errorf("step %s: %s", node.Path, err)
+	}
+
+	node.Experiment = uid
+	e.setNode(node)
+
+	if len(step.Duration) == 0 {
+		return nil
+	}
+
+	if err := sleep(ctx, step); err != nil {
+		return err
+	}
+
+	exp, err := e.exp.FindByUid(context.Background(), uid)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	// the experiment may be recovered by others, such as the duration in the spec of the experiment
+	if exp.Status != core.Success || !utils.Recoverable(exp) {
+		return nil
+	}
+
+	if err := utils.RecoverExp(e.exp, e.chaos, uid); err != nil {
+		return errors.Errorf("step %s: %s", node.Path, err)
+	}
+
+	return nil
+}
+
+// recoverExperiments recovers the running experiments created by the workflow in reverse order of creation
+func (e *Engine) recoverExperiments(workflow string) error {
+	nodes, err := e.workflows.ListNodes(context.Background(), workflow)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	exps := make([]*core.Experiment, 0)
+	for _, node := range nodes {
+		if len(node.Experiment) == 0 {
+			continue
+		}
+
+		exp, err := e.exp.FindByUid(context.Background(), node.Experiment)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		if exp.Status == core.Success && utils.Recoverable(exp) {
+			exps = append(exps, exp)
+		}
+	}
+
+	sort.Slice(exps, func(i, j int) bool {
+		return exps[i].ID > exps[j].ID
+	})
+
+	var errs []string
+	for _, exp := range exps {
+		if err := utils.RecoverExp(e.exp, e.chaos, exp.Uid); err != nil {
+			log.Error("failed to recover experiment of workflow",
+				zap.String("workflow", workflow), zap.String("uid", exp.Uid), zap.Error(err))
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Errorf("failed to recover experiments: %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// recoverInterrupted fails the workflows which were running when chaosd server crashed
+func (e *Engine) recoverInterrupted() {
+	workflows, err := e.workflows.ListByStatus(context.Background(), core.WorkflowRunning)
+	if err != nil {
+		log.Error("failed to list running workflows", zap.Error(err))
+		return
+	}
+
+	for _, wf := range workflows {
+		if err := e.finish(wf, core.WorkflowFailed, "interrupted by the exit of chaosd server"); err != nil {
+			log.Error("failed to finish interrupted workflow", zap.String("uid", wf.Uid), zap.Error(err))
+		}
+	}
+}
+
+// cancelAll cancels all the running workflows, and waits for them to finish
+func (e *Engine) cancelAll(status, message string) {
+	e.Lock()
+	runs := make([]*run, 0, len(e.runs))
+	for _, r := range e.runs {
+		r.status, r.message = status, message
+		r.cancel()
+		runs = append(runs, r)
+	}
+	e.Unlock()
+
+	for _, r := range runs {
+		<-r.done
+	}
+}
+
+func (e *Engine) setNode(node *core.WorkflowNode) {
+	if err := e.workflows.SetNode(context.Background(), node); err != nil {
+		log.Error("failed to update step of workflow",
+			zap.String("workflow", node.Workflow), zap.String("path", node.Path), zap.Error(err))
+	}
+}
+
+// sleep waits for the duration of the step, it returns the error if the context is canceled
+func sleep(ctx context.Context, step *core.WorkflowStep) error {
+	d, err := step.DurationTime()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return errors.WithStack(ctx.Err())
+	}
+}
+
+func joinPath(parent, name string) string {
+	if len(parent) == 0 {
+		return name
+	}
+
+	return parent + "/" + name
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/server/chaosd/chaosdtest"
+	"github.com/chaos-mesh/chaosd/pkg/store/experiment"
+	wfstore "github.com/chaos-mesh/chaosd/pkg/store/workflow"
+)
+
+func TestEngine(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	db := chaosdtest.NewDB(t)
+	engine := NewEngine(experiment.NewStore(db), wfstore.NewStore(db), chaosdtest.NewServer(db))
+	first, second, third := chaosdtest.StartSleepProcess(t), chaosdtest.StartSleepProcess(t), chaosdtest.StartSleepProcess(t)
+
+	spec, err := core.ParseWorkflowSpec([]byte(fmt.Sprintf(`
+name: stop-sleep
+steps:
+- name: stop-first
+  kind: process
+  spec: {action: stop, process: %d, signal: 19}
+- name: wait
+  suspend: 100ms
+- name: stop-others
+  parallel:
+  - name: stop-second
+    kind: process
+    spec: {action: stop, process: %d, signal: 19}
+    duration: 100ms
+  - name: stop-third
+    kind: process
+    spec: {action: stop, process: %d, signal: 19}
+`, first, second, third)))
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	uid, err := engine.Run(spec)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	var status *core.WorkflowStatus
+	g.Eventually(func() string {
+		status, _ = engine.Status(uid)
+		return status.Status
+	}, 5*time.Second).Should(Equal(core.WorkflowSucceeded))
+	g.Expect(status.Nodes).To(HaveLen(5))
+	for _, node := range status.Nodes {
+		g.Expect(node.Status).To(Equal(core.WorkflowSucceeded), node.Path)
+	}
+	// the experiments are recovered when the workflow ends
+	for _, pid := range []int{first, second, third} {
+		g.Expect(chaosdtest.ProcessStatus(pid)).NotTo(Equal("T"))
+	}
+
+	// the workflow is aborted while suspending
+	spec.Steps = spec.Steps[:2]
+	spec.Steps[1].Suspend = "1h"
+	uid, err = engine.Run(spec)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Eventually(func() string {
+		status, err := engine.Status(uid)
+		if err != nil || len(status.Nodes) < 2 {
+			return ""
+		}
+		return status.Nodes[1].Status
+	}).Should(Equal(core.WorkflowRunning))
+	g.Expect(chaosdtest.ProcessStatus(first)).To(Equal("T"))
+
+	g.Expect(engine.Abort(uid)).To(Succeed())
+	status, err = engine.Status(uid)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(status.Status).To(Equal(core.WorkflowAborted))
+	g.Expect(status.Nodes[1].Status).To(Equal(core.WorkflowAborted))
+	g.Expect(chaosdtest.ProcessStatus(first)).NotTo(Equal("T"))
+
+	g.Expect(engine.Abort(uid)).To(Equal(&NotRunningError{Status: core.WorkflowAborted}))
+}
@@ -36,7 +36,12 @@ type DB struct {
 
 // NewDBStore returns a new DB
 func NewDBStore() (*DB, error) {
-	gormDB, err := gorm.Open(sqlite.Open(path.Join(utils.GetProgramPath(), dataFile)), &gorm.Config{
+	return NewDBStoreWithFile(path.Join(utils.GetProgramPath(), dataFile))
+}
+
+// NewDBStoreWithFile returns a new DB stored in the file
+func NewDBStoreWithFile(file string) (*DB, error) {
+	gormDB, err := gorm.Open(sqlite.Open(file), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
 	})
 	if err != nil {
@@ -69,7 +69,7 @@ func (e *experimentStore) ListByConditions(_ context.Context, conds *core.Search
 
 	exps := make([]*core.Experiment, 0)
 
-	db := e.db.Model(core.Experiment{})
+	db := e.filterByConditions(conds)
 
 	if conds.Offset > 0 {
 		db = db.Offset(int(conds.Offset))
@@ -79,16 +79,6 @@ func (e *experimentStore) ListByConditions(_ context.Context, conds *core.Search
 		db = db.Limit(int(conds.Limit))
 	}
 
-	if !conds.All {
-		if len(conds.Kind) > 0 {
-			db = db.Where("kind = ?", conds.Kind)
-		}
-
-		if len(conds.Status) > 0 {
-			db = db.Where("status = ?", conds.Status)
-		}
-	}
-
 	order := "created_at"
 	if !conds.Asc {
 		order += " DESC"
@@ -104,6 +94,38 @@ func (e *experimentStore) ListByConditions(_ context.Context, conds *core.Search
 	return exps, nil
 }
 
+// CountByConditions returns the count of experiments matching the conditions, the offset and limit are ignored.
+func (e *experimentStore) CountByConditions(_ context.Context, conds *core.SearchCommand) (int64, error) {
+	if conds == nil {
+		return 0, errors.New("conditions is required")
+	}
+
+	var count int64
+	if err := e.filterByConditions(conds).
+		Count(&count).
+		Error; err != nil {
+		return 0, perr.WithStack(err)
+	}
+
+	return count, nil
+}
+
+func (e *experimentStore) filterByConditions(conds *core.SearchCommand) *gorm.DB {
+	db := e.db.Model(core.Experiment{})
+
+	if !conds.All {
+		if len(conds.Kind) > 0 {
+			db = db.Where("kind = ?", conds.Kind)
+		}
+
+		if len(conds.Status) > 0 {
+			db = db.Where("status = ?", conds.Status)
+		}
+	}
+
+	return db
+}
+
 func (e *experimentStore) FindByUid(_ context.Context, uid string) (*core.Experiment, error) {
 	exps := make([]*core.Experiment, 0)
 	if err := e.db.
@@ -121,6 +143,39 @@ func (e *experimentStore) FindByUid(_ context.Context, uid string) (*core.Experi
 	return nil, gorm.ErrRecordNotFound
 }
 
+// FindByName returns the latest experiment created by applying the spec of the name.
+func (e *experimentStore) FindByName(_ context.Context, name string) (*core.Experiment, error) {
+	exps := make([]*core.Experiment, 0)
+	if err := e.db.
+		Where("name = ?", name).
+		Order("id DESC").
+		Limit(1).
+		Find(&exps).
+		Error; err != nil {
+		return nil, perr.WithStack(err)
+	}
+
+	if len(exps) > 0 {
+		return exps[0], nil
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+// ListBySchedule returns the experiments created by the schedule, the latest one is the first.
+func (e *experimentStore) ListBySchedule(_ context.Context, schedule string) ([]*core.Experiment, error) {
+	exps := make([]*core.Experiment, 0)
+	if err := e.db.
+		Where("schedule = ?", schedule).
+		Order("id DESC").
+		Find(&exps).
+		Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, perr.WithStack(err)
+	}
+
+	return exps, nil
+}
+
 func (e *experimentStore) Set(_ context.Context, exp *core.Experiment) error {
 	return e.db.Model(core.Experiment{}).Save(exp).Error
 }
@@ -132,3 +187,26 @@ func (e *experimentStore) Update(_ context.Context, uid, status, msg string, com
 		Updates(core.Experiment{Status: status, Message: msg, RecoverCommand: command}).
 		Error
 }
+
+func (e *experimentStore) UpdateCommand(_ context.Context, uid, status, command string) error {
+	return e.db.
+		Model(core.Experiment{}).
+		Where("uid = ? AND status = ?", uid, status).
+		Updates(core.Experiment{RecoverCommand: command}).
+		Error
+}
+
+func (e *experimentStore) UpdateSchedule(_ context.Context, uid, schedule string) error {
+	return e.db.
+		Model(core.Experiment{}).
+		Where("uid = ?", uid).
+		Updates(core.Experiment{Schedule: schedule}).
+		Error
+}
+
+func (e *experimentStore) Delete(_ context.Context, uid string) error {
+	return e.db.
+		Where("uid = ?", uid).
+		Delete(core.Experiment{}).
+		Error
+}
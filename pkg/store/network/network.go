@@ -53,6 +53,18 @@ func (i *ipsetRuleStore) Set(_ context.Context, rule *core.IPSetRule) error {
 	return i.db.Model(core.IPSetRule{}).Save(rule).Error
 }
 
+func (i *ipsetRuleStore) FindByContainer(_ context.Context, container string) ([]*core.IPSetRule, error) {
+	rules := make([]*core.IPSetRule, 0)
+	if err := i.db.
+		Where("container = ?", container).
+		Find(&rules).
+		Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, perr.WithStack(err)
+	}
+
+	return rules, nil
+}
+
 func (i *ipsetRuleStore) FindByExperiment(_ context.Context, experiment string) ([]*core.IPSetRule, error) {
 	rules := make([]*core.IPSetRule, 0)
 	if err := i.db.
@@ -100,6 +112,18 @@ func (i *iptablesRuleStore) Set(_ context.Context, rule *core.IptablesRule) erro
 	return i.db.Model(core.IptablesRule{}).Save(rule).Error
 }
 
+func (i *iptablesRuleStore) FindByContainer(_ context.Context, container string) ([]*core.IptablesRule, error) {
+	rules := make([]*core.IptablesRule, 0)
+	if err := i.db.
+		Where("container = ?", container).
+		Find(&rules).
+		Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, perr.WithStack(err)
+	}
+
+	return rules, nil
+}
+
 func (i *iptablesRuleStore) FindByExperiment(_ context.Context, experiment string) ([]*core.IptablesRule, error) {
 	rules := make([]*core.IptablesRule, 0)
 	if err := i.db.
@@ -147,10 +171,10 @@ func (t *tcRuleStore) List(_ context.Context) ([]*core.TCRule, error) {
 	return rules, nil
 }
 
-func (t *tcRuleStore) FindByDevice(_ context.Context, device string) ([]*core.TCRule, error) {
+func (t *tcRuleStore) FindByDevice(_ context.Context, container string, device string) ([]*core.TCRule, error) {
 	rules := make([]*core.TCRule, 0)
 	if err := t.db.
-		Where("device = ?", device).
+		Where("container = ? AND device = ?", container, device).
 		Find(&rules).
 		Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, perr.WithStack(err)
@@ -177,7 +201,7 @@ func (t *tcRuleStore) DeleteByExperiment(_ context.Context, experiment string) e
 		Error
 }
 
-func (t *tcRuleStore) ListGroupDevice(ctx context.Context) (map[string][]*core.TCRule, error) {
+func (t *tcRuleStore) ListGroupDevice(_ context.Context) (map[string][]*core.TCRule, error) {
 	rules := make(map[string][]*core.TCRule)
 	devices := []string{}
 	if err := t.db.
@@ -190,8 +214,11 @@ func (t *tcRuleStore) ListGroupDevice(ctx context.Context) (map[string][]*core.T
 	}
 
 	for _, device := range devices {
-		rs, err := t.FindByDevice(ctx, device)
-		if err != nil {
+		rs := make([]*core.TCRule, 0)
+		if err := t.db.
+			Where("device = ?", device).
+			Find(&rs).
+			Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, perr.WithStack(err)
 		}
 		rules[device] = rs
@@ -199,3 +226,59 @@ func (t *tcRuleStore) ListGroupDevice(ctx context.Context) (map[string][]*core.T
 
 	return rules, nil
 }
+
+func NewIFBRuleStore(db *dbstore.DB) core.IFBRuleStore {
+	db.AutoMigrate(&core.IFBRule{})
+
+	is := &ifbRuleStore{db}
+
+	return is
+}
+
+type ifbRuleStore struct {
+	db *dbstore.DB
+}
+
+func (i *ifbRuleStore) List(_ context.Context) ([]*core.IFBRule, error) {
+	rules := make([]*core.IFBRule, 0)
+	if err := i.db.
+		Find(&rules).
+		Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, perr.WithStack(err)
+	}
+	return rules, nil
+}
+
+func (i *ifbRuleStore) Set(_ context.Context, rule *core.IFBRule) error {
+	return i.db.Model(core.IFBRule{}).Save(rule).Error
+}
+
+func (i *ifbRuleStore) FindByDevice(_ context.Context, container string, device string) ([]*core.IFBRule, error) {
+	rules := make([]*core.IFBRule, 0)
+	if err := i.db.
+		Where("container = ? AND device = ?", container, device).
+		Find(&rules).
+		Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, perr.WithStack(err)
+	}
+	return rules, nil
+}
+
+func (i *ifbRuleStore) FindByExperiment(_ context.Context, experiment string) ([]*core.IFBRule, error) {
+	rules := make([]*core.IFBRule, 0)
+	if err := i.db.
+		Where("experiment = ?", experiment).
+		Find(&rules).
+		Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, perr.WithStack(err)
+	}
+	return rules, nil
+}
+
+func (i *ifbRuleStore) DeleteByExperiment(_ context.Context, experiment string) error {
+	return i.db.
+		Where("experiment = ?", experiment).
+		Unscoped().
+		Delete(core.IFBRule{}).
+		Error
+}
@@ -0,0 +1,55 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package process
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	perr "github.com/pkg/errors"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/store/dbstore"
+)
+
+func NewEventStore(db *dbstore.DB) core.ProcessEventStore {
+	db.AutoMigrate(&core.ProcessEvent{})
+
+	es := &eventStore{db}
+
+	return es
+}
+
+type eventStore struct {
+	db *dbstore.DB
+}
+
+func (e *eventStore) ListByExperiment(_ context.Context, experiment string) ([]*core.ProcessEvent, error) {
+	events := make([]*core.ProcessEvent, 0)
+	if err := e.db.
+		Where("experiment = ?", experiment).
+		Order("id").
+		Find(&events).
+		Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, perr.WithStack(err)
+	}
+
+	return events, nil
+}
+
+func (e *eventStore) Set(_ context.Context, event *core.ProcessEvent) error {
+	return e.db.Save(event).Error
+}
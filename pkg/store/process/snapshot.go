@@ -0,0 +1,91 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package process
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"gorm.io/gorm"
+
+	perr "github.com/pkg/errors"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/store/dbstore"
+)
+
+// processSnapshot is the record of a snapshot, the snapshot is stored in JSON,
+// so that its environment is only read when the process is restarted.
+type processSnapshot struct {
+	ID         uint   `gorm:"primary_key"`
+	Experiment string `gorm:"index:process_snapshot_experiment"`
+	Pid        int
+	Snapshot   string
+}
+
+func NewSnapshotStore(db *dbstore.DB) core.ProcessSnapshotStore {
+	db.AutoMigrate(&processSnapshot{})
+
+	ss := &snapshotStore{db}
+
+	return ss
+}
+
+type snapshotStore struct {
+	db *dbstore.DB
+}
+
+func (s *snapshotStore) ListByExperiment(_ context.Context, experiment string) ([]*core.ProcessSnapshot, error) {
+	records := make([]*processSnapshot, 0)
+	if err := s.db.
+		Where("experiment = ?", experiment).
+		Order("id").
+		Find(&records).
+		Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, perr.WithStack(err)
+	}
+
+	snapshots := make([]*core.ProcessSnapshot, 0, len(records))
+	for _, record := range records {
+		snapshot := &core.ProcessSnapshot{}
+		if err := json.Unmarshal([]byte(record.Snapshot), snapshot); err != nil {
+			return nil, perr.WithStack(err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, nil
+}
+
+func (s *snapshotStore) Set(_ context.Context, experiment string, snapshot *core.ProcessSnapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return perr.WithStack(err)
+	}
+
+	record := &processSnapshot{}
+	if err := s.db.
+		Where("experiment = ? AND pid = ?", experiment, snapshot.Pid).
+		First(record).
+		Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return perr.WithStack(err)
+	}
+
+	record.Experiment = experiment
+	record.Pid = snapshot.Pid
+	record.Snapshot = string(data)
+
+	return s.db.Save(record).Error
+}
@@ -0,0 +1,77 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	perr "github.com/pkg/errors"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/store/dbstore"
+)
+
+func NewStore(db *dbstore.DB) core.ScheduleStore {
+	db.AutoMigrate(&core.Schedule{})
+
+	ss := &scheduleStore{db}
+
+	return ss
+}
+
+type scheduleStore struct {
+	db *dbstore.DB
+}
+
+func (s *scheduleStore) List(_ context.Context) ([]*core.Schedule, error) {
+	schedules := make([]*core.Schedule, 0)
+	if err := s.db.
+		Order("id").
+		Find(&schedules).
+		Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, perr.WithStack(err)
+	}
+
+	return schedules, nil
+}
+
+func (s *scheduleStore) FindByUid(_ context.Context, uid string) (*core.Schedule, error) {
+	schedules := make([]*core.Schedule, 0)
+	if err := s.db.
+		Where("uid = ?", uid).
+		Find(&schedules).
+		Error; err != nil {
+		return nil, perr.WithStack(err)
+	}
+
+	if len(schedules) > 0 {
+		return schedules[0], nil
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *scheduleStore) Set(_ context.Context, schedule *core.Schedule) error {
+	return s.db.Save(schedule).Error
+}
+
+func (s *scheduleStore) Delete(_ context.Context, uid string) error {
+	return s.db.
+		Where("uid = ?", uid).
+		Delete(core.Schedule{}).
+		Error
+}
@@ -19,6 +19,10 @@ import (
 	"github.com/chaos-mesh/chaosd/pkg/store/dbstore"
 	"github.com/chaos-mesh/chaosd/pkg/store/experiment"
 	"github.com/chaos-mesh/chaosd/pkg/store/network"
+	"github.com/chaos-mesh/chaosd/pkg/store/process"
+	"github.com/chaos-mesh/chaosd/pkg/store/schedule"
+	"github.com/chaos-mesh/chaosd/pkg/store/timer"
+	"github.com/chaos-mesh/chaosd/pkg/store/workflow"
 )
 
 var Module = fx.Options(
@@ -28,5 +32,11 @@ var Module = fx.Options(
 		network.NewIPSetRuleStore,
 		network.NewIptablesRuleStore,
 		network.NewTCRuleStore,
+		network.NewIFBRuleStore,
+		timer.NewStore,
+		process.NewEventStore,
+		process.NewSnapshotStore,
+		schedule.NewStore,
+		workflow.NewStore,
 	),
 )
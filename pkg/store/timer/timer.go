@@ -0,0 +1,76 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timer
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	perr "github.com/pingcap/errors"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/store/dbstore"
+)
+
+func NewStore(db *dbstore.DB) core.TimerStore {
+	db.AutoMigrate(&core.Timer{})
+
+	ts := &timerStore{db}
+
+	return ts
+}
+
+type timerStore struct {
+	db *dbstore.DB
+}
+
+func (t *timerStore) List(_ context.Context) ([]*core.Timer, error) {
+	timers := make([]*core.Timer, 0)
+	if err := t.db.
+		Order("deadline").
+		Find(&timers).
+		Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, perr.WithStack(err)
+	}
+
+	return timers, nil
+}
+
+func (t *timerStore) ListExpired(_ context.Context, now time.Time) ([]*core.Timer, error) {
+	timers := make([]*core.Timer, 0)
+	if err := t.db.
+		Where("deadline <= ?", now).
+		Order("deadline").
+		Find(&timers).
+		Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, perr.WithStack(err)
+	}
+
+	return timers, nil
+}
+
+func (t *timerStore) Set(_ context.Context, timer *core.Timer) error {
+	return t.db.Model(core.Timer{}).Save(timer).Error
+}
+
+func (t *timerStore) DeleteByExperiment(_ context.Context, experiment string) error {
+	return t.db.
+		Where("experiment = ?", experiment).
+		Unscoped().
+		Delete(core.Timer{}).
+		Error
+}
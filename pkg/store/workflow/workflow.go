@@ -0,0 +1,108 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	perr "github.com/pkg/errors"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+	"github.com/chaos-mesh/chaosd/pkg/store/dbstore"
+)
+
+func NewStore(db *dbstore.DB) core.WorkflowStore {
+	db.AutoMigrate(&core.Workflow{}, &core.WorkflowNode{})
+
+	ws := &workflowStore{db}
+
+	return ws
+}
+
+type workflowStore struct {
+	db *dbstore.DB
+}
+
+func (w *workflowStore) List(_ context.Context) ([]*core.Workflow, error) {
+	workflows := make([]*core.Workflow, 0)
+	if err := w.db.
+		Order("id DESC").
+		Find(&workflows).
+		Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, perr.WithStack(err)
+	}
+
+	return workflows, nil
+}
+
+func (w *workflowStore) ListByStatus(_ context.Context, status string) ([]*core.Workflow, error) {
+	workflows := make([]*core.Workflow, 0)
+	if err := w.db.
+		Where("status = ?", status).
+		Find(&workflows).
+		Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, perr.WithStack(err)
+	}
+
+	return workflows, nil
+}
+
+func (w *workflowStore) FindByUid(_ context.Context, uid string) (*core.Workflow, error) {
+	workflows := make([]*core.Workflow, 0)
+	if err := w.db.
+		Where("uid = ?", uid).
+		Find(&workflows).
+		Error; err != nil {
+		return nil, perr.WithStack(err)
+	}
+
+	if len(workflows) > 0 {
+		return workflows[0], nil
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (w *workflowStore) Set(_ context.Context, workflow *core.Workflow) error {
+	return w.db.Model(core.Workflow{}).Save(workflow).Error
+}
+
+func (w *workflowStore) Update(_ context.Context, uid, status, msg string) error {
+	return w.db.
+		Model(core.Workflow{}).
+		Where("uid = ?", uid).
+		Updates(core.Workflow{Status: status, Message: msg}).
+		Error
+}
+
+// ListNodes returns the steps of the workflow in the order of the spec
+func (w *workflowStore) ListNodes(_ context.Context, workflow string) ([]*core.WorkflowNode, error) {
+	nodes := make([]*core.WorkflowNode, 0)
+	if err := w.db.
+		Where("workflow = ?", workflow).
+		Order("id").
+		Find(&nodes).
+		Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, perr.WithStack(err)
+	}
+
+	return nodes, nil
+}
+
+func (w *workflowStore) SetNode(_ context.Context, node *core.WorkflowNode) error {
+	return w.db.Save(node).Error
+}
@@ -0,0 +1,51 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stress
+
+import (
+	"context"
+	"runtime"
+	"time"
+)
+
+// cpuSlice is the period of the duty cycle of the cpu workers
+const cpuSlice = 100 * time.Millisecond
+
+// cpuWorker keeps a thread busy for the load percent of each slice and sleeps for the rest of it
+func cpuWorker(ctx context.Context, _ int, opts *Options) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	// the same as stress-ng, 0 is effectively a sleep and 100 is full loading
+	load := opts.Load
+	if load < 0 {
+		load = 0
+	} else if load > 100 {
+		load = 100
+	}
+
+	busy := cpuSlice * time.Duration(load) / 100
+	for {
+		start := time.Now()
+		for time.Since(start) < busy {
+			// spin
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(cpuSlice - busy):
+		}
+	}
+}
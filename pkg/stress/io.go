@@ -0,0 +1,142 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stress
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pingcap/errors"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+const (
+	// defaultIOBytes is the file size written by each io worker, the same as the hdd stressor of stress-ng
+	defaultIOBytes = 1 << 30
+	// ioBlock is the size of each read and write of the io workers
+	ioBlock = 1 << 20
+	// mixBlock is the size of the random reads and writes of the mix mode
+	mixBlock = 4 << 10
+)
+
+// ioWorker writes and reads a file in the temporary directory, the file is removed when the worker exits
+func ioWorker(ctx context.Context, i int, opts *Options) error {
+	size := int64(opts.Bytes)
+	if size == 0 {
+		size = defaultIOBytes
+	}
+
+	path := filepath.Join(opts.TempDir, fmt.Sprintf("chaosd-stressor-%d", i))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		f.Close()
+		os.Remove(path)
+	}()
+
+	buf := make([]byte, ioBlock)
+	rand.Read(buf)
+
+	for ctx.Err() == nil {
+		if err := writeSeq(ctx, f, buf, size); err != nil {
+			return errors.WithStack(err)
+		}
+
+		switch opts.Mode {
+		case core.StressIOReadWrite:
+			err = readSeq(ctx, f, buf, size)
+		case core.StressIOMix:
+			err = mixRandom(ctx, f, buf[:mixBlock], size)
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// writeSeq writes the file from the beginning sequentially
+func writeSeq(ctx context.Context, f *os.File, buf []byte, size int64) error {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	for written := int64(0); written < size && ctx.Err() == nil; {
+		n := int64(len(buf))
+		if size-written < n {
+			n = size - written
+		}
+
+		if _, err := f.Write(buf[:n]); err != nil {
+			return err
+		}
+		written += n
+	}
+
+	return f.Sync()
+}
+
+// readSeq reads the file from the beginning sequentially
+func readSeq(ctx context.Context, f *os.File, buf []byte, size int64) error {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	for read := int64(0); read < size && ctx.Err() == nil; {
+		n, err := f.Read(buf)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		read += int64(n)
+	}
+
+	return nil
+}
+
+// mixRandom reads and writes the blocks at random offsets of the file, as many as the blocks of the file
+func mixRandom(ctx context.Context, f *os.File, block []byte, size int64) error {
+	blocks := size / int64(len(block))
+	if blocks == 0 {
+		return nil
+	}
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := int64(0); i < blocks && ctx.Err() == nil; i++ {
+		offset := r.Int63n(blocks) * int64(len(block))
+
+		var err error
+		if i%2 == 0 {
+			_, err = f.WriteAt(block, offset)
+		} else {
+			_, err = f.ReadAt(block, offset)
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+	}
+
+	return f.Sync()
+}
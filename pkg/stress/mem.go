@@ -0,0 +1,101 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stress
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/pingcap/errors"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// heapChunk is the memory allocated at a time when the memory worker grows without a size
+	heapChunk = 4 << 20
+	// allocRetryInterval is the interval to retry after the memory is failed to allocate
+	allocRetryInterval = 100 * time.Millisecond
+)
+
+// memWorker maps the memory and touches every page of it, so the memory is resident.
+// Without the bytes, it grows the memory until it is killed, like the bigheap stressor of stress-ng.
+func memWorker(ctx context.Context, _ int, opts *Options) error {
+	if opts.Bytes == 0 {
+		return growHeap(ctx)
+	}
+
+	for {
+		mem, err := mmap(int(opts.Bytes))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		touch(mem, 0)
+
+		switch {
+		case opts.Hang:
+			<-ctx.Done()
+		case opts.Keep:
+			for round := byte(1); ctx.Err() == nil; round++ {
+				touch(mem, round)
+			}
+		}
+
+		if err := unix.Munmap(mem); err != nil {
+			return errors.WithStack(err)
+		}
+
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+func growHeap(ctx context.Context) error {
+	var chunks [][]byte
+	defer func() {
+		for _, chunk := range chunks {
+			_ = unix.Munmap(chunk)
+		}
+	}()
+
+	for ctx.Err() == nil {
+		chunk, err := mmap(heapChunk)
+		if err != nil {
+			// keep the allocated memory and try again, it is killed by OOM or recovered at last
+			select {
+			case <-ctx.Done():
+			case <-time.After(allocRetryInterval):
+			}
+			continue
+		}
+
+		touch(chunk, 0)
+		chunks = append(chunks, chunk)
+	}
+
+	return nil
+}
+
+func mmap(size int) ([]byte, error) {
+	return unix.Mmap(-1, 0, size, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_ANON|unix.MAP_PRIVATE)
+}
+
+// touch writes every page of the memory, the value is changed in each round so the pages are dirtied again
+func touch(mem []byte, round byte) {
+	pageSize := os.Getpagesize()
+	for i := 0; i < len(mem); i += pageSize {
+		mem[i] = round + 1
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package stress implements the builtin stressors of chaosd, which are used when stress-ng is not installed.
+// The stressors run in a child process of chaosd started by the hidden stressor command.
+package stress
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+// CommandName is the name of the hidden chaosd command which runs the builtin stressors
+const CommandName = "stressor"
+
+// Options defines the builtin stressors to run.
+type Options struct {
+	Action string
+
+	Workers int
+	// Load is the percent of the CPU time used by each cpu worker
+	Load int
+	// Bytes is the memory allocated or the file written by each worker,
+	// 0 means growing the memory until the worker is killed, or the default file size for the io workers.
+	Bytes uint64
+	// Hang holds the memory after it is allocated
+	Hang bool
+	// Keep keeps rewriting the memory instead of unmapping and mapping it again
+	Keep bool
+	// Mode is the read and write mix of the io workers
+	Mode string
+	// TempDir is the directory of the files written by the io workers
+	TempDir string
+	// Timeout stops the stressors after the duration, 0 means running until the process is killed
+	Timeout time.Duration
+}
+
+// Run runs the stressors until the timeout is reached or the context is canceled.
+func Run(ctx context.Context, opts *Options) error {
+	var worker func(context.Context, int, *Options) error
+	switch opts.Action {
+	case core.StressCPUAction:
+		worker = cpuWorker
+	case core.StressMemAction:
+		worker = memWorker
+	case core.StressIOAction:
+		worker = ioWorker
+	default:
+		return errors.Errorf("stress action %s not supported", opts.Action)
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		// the same as stress-ng, 0 means one worker per CPU
+		workers = runtime.NumCPU()
+	}
+
+	if workers > runtime.GOMAXPROCS(0) {
+		// each cpu worker keeps a thread busy
+		runtime.GOMAXPROCS(workers)
+	}
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			if err := worker(ctx, i, opts); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	return firstErr
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stress
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/chaos-mesh/chaosd/pkg/core"
+)
+
+func TestRun(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	dir, err := ioutil.TempDir("", "chaosd-stressor")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	type TestCase struct {
+		name        string
+		opts        Options
+		expectedErr bool
+	}
+
+	tcs := []TestCase{
+		{name: "cpu", opts: Options{Action: core.StressCPUAction, Workers: 1, Load: 20}},
+		{name: "mem hang", opts: Options{Action: core.StressMemAction, Workers: 2, Bytes: 1 << 20, Hang: true}},
+		{name: "mem keep", opts: Options{Action: core.StressMemAction, Workers: 1, Bytes: 1 << 20, Keep: true}},
+		{name: "io mix", opts: Options{Action: core.StressIOAction, Workers: 2, Bytes: 1 << 20, Mode: core.StressIOMix, TempDir: dir}},
+		{name: "io without temp dir", opts: Options{Action: core.StressIOAction, Workers: 1, TempDir: dir + "/missing"}, expectedErr: true},
+		{name: "unknown action", opts: Options{Action: "disk"}, expectedErr: true},
+	}
+
+	for _, tc := range tcs {
+		tc.opts.Timeout = 200 * time.Millisecond
+		err := Run(context.Background(), &tc.opts)
+		if tc.expectedErr {
+			g.Expect(err).To(HaveOccurred(), tc.name)
+		} else {
+			g.Expect(err).NotTo(HaveOccurred(), tc.name)
+		}
+	}
+
+	// the files written by the io workers are removed when they exit
+	files, err := ioutil.ReadDir(dir)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(files).To(BeEmpty())
+}
@@ -17,6 +17,7 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func CheckPorts(p string) bool {
@@ -102,3 +103,16 @@ func CheckPercent(p string) bool {
 
 	return true
 }
+
+func CheckDuration(d string) bool {
+	if len(d) == 0 {
+		return true
+	}
+
+	v, err := time.ParseDuration(d)
+	if err != nil {
+		return false
+	}
+
+	return v > 0
+}
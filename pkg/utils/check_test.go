@@ -97,3 +97,45 @@ func TestCheckIPs(t *testing.T) {
 		g.Expect(CheckIPs(tc.ips)).To(Equal(tc.expectedValue), tc.name)
 	}
 }
+
+func TestCheckDuration(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type TestCase struct {
+		name          string
+		duration      string
+		expectedValue bool
+	}
+
+	tcs := []TestCase{
+		{
+			name:          "empty duration",
+			duration:      "",
+			expectedValue: true,
+		},
+		{
+			name:          "valid duration",
+			duration:      "5m30s",
+			expectedValue: true,
+		},
+		{
+			name:          "without unit",
+			duration:      "300",
+			expectedValue: false,
+		},
+		{
+			name:          "zero duration",
+			duration:      "0s",
+			expectedValue: false,
+		},
+		{
+			name:          "negative duration",
+			duration:      "-1m",
+			expectedValue: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		g.Expect(CheckDuration(tc.duration)).To(Equal(tc.expectedValue), tc.name)
+	}
+}
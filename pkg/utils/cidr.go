@@ -15,15 +15,43 @@ package utils
 
 import (
 	"net"
-	"strings"
 )
 
 // IPToCidr converts from an ip to a full mask cidr
 func IPToCidr(ip string) string {
-	// TODO: support IPv6
+	if IsIPv6(ip) {
+		return ip + "/128"
+	}
+
 	return ip + "/32"
 }
 
+// IsIPv6 returns true if the ip or cidr is an IPv6 address
+func IsIPv6(ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		var err error
+		if addr, _, err = net.ParseCIDR(ip); err != nil {
+			return false
+		}
+	}
+
+	return addr.To4() == nil
+}
+
+// SplitCidrsByFamily splits the cidrs into IPv4 cidrs and IPv6 cidrs
+func SplitCidrsByFamily(cidrs []string) (ipv4 []string, ipv6 []string) {
+	for _, cidr := range cidrs {
+		if IsIPv6(cidr) {
+			ipv6 = append(ipv6, cidr)
+		} else {
+			ipv4 = append(ipv4, cidr)
+		}
+	}
+
+	return
+}
+
 // ResolveCidrs converts multiple cidrs/ips/domains into cidr
 func ResolveCidrs(names []string) ([]string, error) {
 	cidrs := []string{}
@@ -58,12 +86,7 @@ func ResolveCidr(name string) ([]string, error) {
 
 	cidrs := []string{}
 	for _, addr := range addrs {
-		addr := addr.String()
-
-		// TODO: support IPv6
-		if strings.Contains(addr, ".") {
-			cidrs = append(cidrs, IPToCidr(addr))
-		}
+		cidrs = append(cidrs, IPToCidr(addr.String()))
 	}
 	return cidrs, nil
 }
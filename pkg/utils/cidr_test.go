@@ -0,0 +1,67 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestResolveCidr(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type TestCase struct {
+		name          string
+		target        string
+		expectedValue []string
+	}
+
+	tcs := []TestCase{
+		{
+			name:          "ipv4 address",
+			target:        "172.16.4.4",
+			expectedValue: []string{"172.16.4.4/32"},
+		},
+		{
+			name:          "ipv4 cidr",
+			target:        "172.16.4.4/24",
+			expectedValue: []string{"172.16.4.0/24"},
+		},
+		{
+			name:          "ipv6 address",
+			target:        "2001:db8::1",
+			expectedValue: []string{"2001:db8::1/128"},
+		},
+		{
+			name:          "ipv6 cidr",
+			target:        "2001:db8::1/64",
+			expectedValue: []string{"2001:db8::/64"},
+		},
+	}
+
+	for _, tc := range tcs {
+		cidrs, err := ResolveCidr(tc.target)
+		g.Expect(err).ShouldNot(HaveOccurred(), tc.name)
+		g.Expect(cidrs).To(Equal(tc.expectedValue), tc.name)
+	}
+}
+
+func TestSplitCidrsByFamily(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ipv4, ipv6 := SplitCidrsByFamily([]string{"172.16.4.4/32", "2001:db8::1/128", "10.0.0.0/8"})
+	g.Expect(ipv4).To(Equal([]string{"172.16.4.4/32", "10.0.0.0/8"}))
+	g.Expect(ipv6).To(Equal([]string{"2001:db8::1/128"}))
+}
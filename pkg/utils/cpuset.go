@@ -0,0 +1,61 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/pingcap/errors"
+	"golang.org/x/sys/unix"
+)
+
+// cpuSetSize is the count of CPUs in the CPU set of the kernel, which is CPU_SETSIZE
+const cpuSetSize = int(unsafe.Sizeof(unix.CPUSet{})) * 8
+
+// ParseCPUList parses the list of CPUs in the format of cpuset, such as 2-3 or 0,2,4-7
+func ParseCPUList(list string) ([]int, error) {
+	var cpus []int
+	seen := map[int]struct{}{}
+	for _, part := range strings.Split(list, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(part), "-", 2)
+
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil || first < 0 {
+			return nil, errors.Errorf("cpu list %s not valid", list)
+		}
+
+		last := first
+		if len(bounds) == 2 {
+			if last, err = strconv.Atoi(bounds[1]); err != nil || last < first {
+				return nil, errors.Errorf("cpu list %s not valid", list)
+			}
+		}
+
+		// the CPUs beyond the CPU set can't be pinned
+		if last >= cpuSetSize {
+			return nil, errors.Errorf("cpu list %s not valid, the cpu should be less than %d", list, cpuSetSize)
+		}
+
+		for cpu := first; cpu <= last; cpu++ {
+			if _, ok := seen[cpu]; !ok {
+				seen[cpu] = struct{}{}
+				cpus = append(cpus, cpu)
+			}
+		}
+	}
+
+	return cpus, nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseCPUList(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type TestCase struct {
+		name          string
+		list          string
+		expectedValue []int
+		expectedErr   bool
+	}
+
+	tcs := []TestCase{
+		{name: "single cpu", list: "2", expectedValue: []int{2}},
+		{name: "range", list: "2-3", expectedValue: []int{2, 3}},
+		{name: "mixed", list: "0,2,4-6", expectedValue: []int{0, 2, 4, 5, 6}},
+		{name: "overlapped", list: "1-3,2", expectedValue: []int{1, 2, 3}},
+		{name: "reversed range", list: "3-2", expectedErr: true},
+		{name: "empty part", list: "1,", expectedErr: true},
+		{name: "not a number", list: "a-b", expectedErr: true},
+		{name: "huge range", list: "0-2147483647", expectedErr: true},
+		{name: "cpu beyond the cpu set", list: "1024", expectedErr: true},
+	}
+
+	for _, tc := range tcs {
+		cpus, err := ParseCPUList(tc.list)
+		if tc.expectedErr {
+			g.Expect(err).To(HaveOccurred(), tc.name)
+			continue
+		}
+
+		g.Expect(err).NotTo(HaveOccurred(), tc.name)
+		g.Expect(cpus).To(Equal(tc.expectedValue), tc.name)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/errors"
+)
+
+var sizeUnits = map[byte]uint64{
+	'b': 1,
+	'k': 1 << 10,
+	'm': 1 << 20,
+	'g': 1 << 30,
+}
+
+// ParseSize parses the size in bytes with the unit b, k, m or g, such as 512m,
+// or the percent of the total, such as 80%.
+func ParseSize(size string, total uint64) (uint64, error) {
+	s := strings.ToLower(strings.TrimSpace(size))
+	if len(s) == 0 {
+		return 0, errors.Errorf("size %q not valid", size)
+	}
+
+	if strings.HasSuffix(s, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return 0, errors.Errorf("size %q not valid, the percent should be in [0, 100]", size)
+		}
+
+		return uint64(float64(total) * percent / 100), nil
+	}
+
+	unit := uint64(1)
+	if u, ok := sizeUnits[s[len(s)-1]]; ok {
+		unit = u
+		s = s[:len(s)-1]
+	}
+
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, errors.Errorf("size %q not valid, it should be bytes with the unit b, k, m, g or a percent", size)
+	}
+
+	if v > math.MaxUint64/unit {
+		return 0, errors.Errorf("size %q not valid, it overflows", size)
+	}
+
+	return v * unit, nil
+}
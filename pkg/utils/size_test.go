@@ -0,0 +1,53 @@
+// Copyright 2020 Chaos Mesh Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseSize(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	type TestCase struct {
+		name          string
+		size          string
+		expectedValue uint64
+		expectedErr   bool
+	}
+
+	tcs := []TestCase{
+		{name: "bytes", size: "1024", expectedValue: 1024},
+		{name: "megabytes", size: "512m", expectedValue: 512 << 20},
+		{name: "upper case unit", size: "6G", expectedValue: 6 << 30},
+		{name: "percent", size: "25%", expectedValue: 1 << 30},
+		{name: "percent over 100", size: "120%", expectedErr: true},
+		{name: "unknown unit", size: "1t", expectedErr: true},
+		{name: "overflow", size: "17179869184g", expectedErr: true},
+		{name: "empty", size: "", expectedErr: true},
+	}
+
+	for _, tc := range tcs {
+		v, err := ParseSize(tc.size, 4<<30)
+		if tc.expectedErr {
+			g.Expect(err).To(HaveOccurred(), tc.name)
+			continue
+		}
+
+		g.Expect(err).NotTo(HaveOccurred(), tc.name)
+		g.Expect(v).To(Equal(tc.expectedValue), tc.name)
+	}
+}